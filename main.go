@@ -5,10 +5,11 @@ import (
 
 	"github.com/PeepoFrog/sekai_manager/src/cfg"
 	"github.com/PeepoFrog/sekai_manager/src/cmd"
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
 )
 
 func main() {
-	cfg, err := cfg.DefaultCfg()
+	cfg, err := cfg.LoadConfig("", instancesmanager.ConfigValidators)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -17,6 +17,8 @@ import (
 const (
 	MANAGER_HOME_FOLDER_NAME string = ".sekaid_manager"
 	MANAGER_CONFIG_FILE_NAME string = "cfg.toml"
+	INSTANCES_FOLDER_NAME    string = "instances"
+	CACHE_FOLDER_NAME        string = "cache"
 )
 
 func DefaultCfg() (*types.ManagerConfig, error) {
@@ -28,8 +30,9 @@ func DefaultCfg() (*types.ManagerConfig, error) {
 	cfgPath := filepath.Join(homePath, MANAGER_CONFIG_FILE_NAME)
 
 	return &types.ManagerConfig{
-		Home:       homePath,
-		ConfigPath: cfgPath,
+		SchemaVersion: CURRENT_SCHEMA_VERSION,
+		Home:          homePath,
+		ConfigPath:    cfgPath,
 	}, nil
 
 }
@@ -52,15 +55,22 @@ func GenerateConfigFile(cfg *types.ManagerConfig) (string, error) {
 		return "", err
 	}
 
+	// Always write the schema this binary understands
+	cfg.SchemaVersion = CURRENT_SCHEMA_VERSION
+
 	// Marshal to TOML
 	b, err := toml.Marshal(cfg)
 	if err != nil {
 		return "", err
 	}
 
-	// Write file
+	// Write file; keep it private once it carries a token
 	path := cfg.ConfigPath
-	if err := os.WriteFile(path, b, 0o644); err != nil {
+	perm := os.FileMode(0o644)
+	if cfg.GitHub.Token != "" {
+		perm = 0o600
+	}
+	if err := writeFileAtomic(path, b, perm); err != nil {
 		return "", err
 	}
 	return path, nil
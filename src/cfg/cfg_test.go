@@ -0,0 +1,54 @@
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateConfigFileMode(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c, err := DefaultCfg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := GenerateConfigFile(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMode(t, path, 0o644)
+
+	// a token makes an existing world-readable file private
+	c.GitHub.Token = "secret"
+	if _, err := GenerateConfigFile(c); err != nil {
+		t.Fatal(err)
+	}
+	assertMode(t, path, 0o600)
+
+	c.GitHub.Token = ""
+	if _, err := GenerateConfigFile(c); err != nil {
+		t.Fatal(err)
+	}
+	assertMode(t, path, 0o644)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != filepath.Base(path) && !e.IsDir() {
+			t.Errorf("left behind %s", e.Name())
+		}
+	}
+}
+
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mode().Perm() != want {
+		t.Errorf("%s mode %o, want %o", path, st.Mode().Perm(), want)
+	}
+}
@@ -0,0 +1,26 @@
+package cfg
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+// ValidateGitHubConfig checks the API base URL and the rate limit wait.
+func ValidateGitHubConfig(gc types.GitHubConfig) error {
+	if gc.APIBaseURL != "" {
+		u, err := url.Parse(gc.APIBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("api_base_url %q is not an http(s) url", gc.APIBaseURL)
+		}
+	}
+	if gc.RateLimitWait != "" {
+		d, err := time.ParseDuration(gc.RateLimitWait)
+		if err != nil || d < 0 {
+			return fmt.Errorf("rate_limit_wait %q is not a duration like 10m", gc.RateLimitWait)
+		}
+	}
+	return nil
+}
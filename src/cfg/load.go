@@ -0,0 +1,292 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PeepoFrog/sekai_manager/src/types"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// CURRENT_SCHEMA_VERSION is the schema_version written by GenerateConfigFile.
+// Bump it together with a new entry in migrations.
+const CURRENT_SCHEMA_VERSION int = 2
+
+// Validators check the fields whose format is owned by the installer and key
+// derivation packages; cfg does not import them, the caller hands the checks in.
+// A nil check is skipped.
+type Validators struct {
+	TrustedKeys func(keys []string) error
+	AssetRules  func(rules types.AssetRules) error
+	// VersionSpec checks sekaid_version, Version an exact resolved_version.
+	VersionSpec func(spec string) error
+	Version     func(version string) error
+	Source      func(source string) error
+	Derivation  func(d types.KeyDerivation) error
+}
+
+func check[T any](f func(T) error, v T) error {
+	if f == nil {
+		return nil
+	}
+	return f(v)
+}
+
+// migration upgrades a raw decoded config from version N to N+1 in place.
+type migration func(raw map[string]any) error
+
+// migrations[i] upgrades schema i to i+1.
+var migrations = []migration{
+	migrateV0ToV1,
+	migrateV1ToV2,
+}
+
+// LoadConfig reads the config file at path and merges it over DefaultCfg.
+// If path is empty the default config path is used. A missing file is not an error,
+// the defaults are returned instead. Older schemas are migrated and the result is validated
+// with v; instances whose home is missing still load, CheckInstanceHome tells them apart.
+func LoadConfig(path string, v Validators) (*types.ManagerConfig, error) {
+	cfg, err := DefaultCfg()
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = cfg.ConfigPath
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		cfg.ConfigPath = path
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := toml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	if err := Migrate(raw); err != nil {
+		return nil, fmt.Errorf("unable to migrate %s: %w", path, err)
+	}
+
+	// Re-encode the migrated document and decode it over the defaults,
+	// so that keys missing from the file keep their default values.
+	migrated, err := toml.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	dec := toml.NewDecoder(bytes.NewReader(migrated))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", path, err)
+	}
+	cfg.ConfigPath = path
+
+	if err := ValidateConfig(cfg, v); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Migrate upgrades a raw config document to CURRENT_SCHEMA_VERSION in place.
+func Migrate(raw map[string]any) error {
+	v, err := schemaVersionOf(raw)
+	if err != nil {
+		return err
+	}
+	if v > CURRENT_SCHEMA_VERSION {
+		return fmt.Errorf("config schema_version %d is newer than supported %d", v, CURRENT_SCHEMA_VERSION)
+	}
+	for ; v < CURRENT_SCHEMA_VERSION; v++ {
+		if err := migrations[v](raw); err != nil {
+			return fmt.Errorf("migration %d -> %d: %w", v, v+1, err)
+		}
+		raw["schema_version"] = int64(v + 1)
+	}
+	return nil
+}
+
+func schemaVersionOf(raw map[string]any) (int, error) {
+	v, ok := raw["schema_version"]
+	if !ok {
+		// files written before schema_version existed
+		return 0, nil
+	}
+	n, ok := v.(int64)
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("invalid schema_version %v", v)
+	}
+	return int(n), nil
+}
+
+// migrateV0ToV1 fills the instance home for entries that were written without one.
+func migrateV0ToV1(raw map[string]any) error {
+	home, _ := raw["home"].(string)
+	instances, ok := raw["instances"].([]any)
+	if !ok {
+		return nil
+	}
+	for _, it := range instances {
+		inst, ok := it.(map[string]any)
+		if !ok {
+			return fmt.Errorf("malformed instance entry %v", it)
+		}
+		if h, _ := inst["home"].(string); h != "" {
+			continue
+		}
+		name, _ := inst["name"].(string)
+		if home == "" || name == "" {
+			continue
+		}
+		inst["home"] = filepath.Join(home, INSTANCES_FOLDER_NAME, name)
+	}
+	return nil
+}
+
+// migrateV1ToV2 stores the address binding derived from port_range with every instance.
+func migrateV1ToV2(raw map[string]any) error {
+	instances, ok := raw["instances"].([]any)
+	if !ok {
+		return nil
+	}
+	for _, it := range instances {
+		inst, ok := it.(map[string]any)
+		if !ok {
+			return fmt.Errorf("malformed instance entry %v", it)
+		}
+		if _, ok := inst["addresses"]; ok {
+			continue
+		}
+		n, _ := inst["port_range"].(int64)
+		ab, err := AddressBindingForRange(int(n))
+		if err != nil {
+			return fmt.Errorf("instance %v: %w", inst["name"], err)
+		}
+		b, err := toml.Marshal(ab.Record())
+		if err != nil {
+			return err
+		}
+		var addresses map[string]any
+		if err := toml.Unmarshal(b, &addresses); err != nil {
+			return err
+		}
+		inst["addresses"] = addresses
+	}
+	return nil
+}
+
+// ValidateConfig checks the manager config and every registered instance.
+func ValidateConfig(cfg *types.ManagerConfig, v Validators) error {
+	if cfg == nil {
+		return errors.New("cfg is nil")
+	}
+	var errs []string
+
+	if cfg.Home == "" {
+		errs = append(errs, "home is empty")
+	}
+	if cfg.ConfigPath == "" {
+		errs = append(errs, "config_path is empty")
+	}
+
+	if err := check(v.TrustedKeys, cfg.TrustedKeys); err != nil {
+		errs = append(errs, fmt.Sprintf("trusted_keys: %v", err))
+	}
+	if err := check(v.AssetRules, cfg.AssetRules); err != nil {
+		errs = append(errs, fmt.Sprintf("asset_rules: %v", err))
+	}
+	if err := ValidateGitHubConfig(cfg.GitHub); err != nil {
+		errs = append(errs, fmt.Sprintf("github: %v", err))
+	}
+
+	names := make(map[string]struct{}, len(cfg.Instances))
+	homes := make(map[string]string, len(cfg.Instances))
+	for i, ic := range cfg.Instances {
+		if err := ValidateInstanceConfig(ic, v); err != nil {
+			errs = append(errs, fmt.Sprintf("instances[%d]: %v", i, err))
+		}
+		if _, dup := names[ic.Name]; dup {
+			errs = append(errs, fmt.Sprintf("instances[%d]: duplicate name %q", i, ic.Name))
+		}
+		names[ic.Name] = struct{}{}
+		if ic.Home != "" {
+			h := filepath.Clean(ic.Home)
+			if other, dup := homes[h]; dup {
+				errs = append(errs, fmt.Sprintf("instances[%d]: home %s is shared with %q", i, h, other))
+			}
+			homes[h] = ic.Name
+		}
+	}
+
+	if err := CheckPortCollisions(cfg.Instances); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n  - %s", strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// ValidateInstanceConfig checks a single instance entry. The instance home is
+// not required to exist, see CheckInstanceHome.
+func ValidateInstanceConfig(ic types.InstanceConfig, v Validators) error {
+	var errs []string
+
+	if ic.Name == "" {
+		errs = append(errs, "name is empty")
+	}
+	if ic.PortRange < 0 {
+		errs = append(errs, fmt.Sprintf("port_range %d is negative", ic.PortRange))
+	}
+	if ic.Home == "" {
+		errs = append(errs, "home is empty")
+	}
+	if ic.SekaidVersion != "" {
+		if err := check(v.VersionSpec, ic.SekaidVersion); err != nil {
+			errs = append(errs, fmt.Sprintf("sekaid_version: %v", err))
+		}
+	}
+	if ic.ResolvedVersion != "" {
+		if err := check(v.Version, ic.ResolvedVersion); err != nil {
+			errs = append(errs, fmt.Sprintf("resolved_version: %v", err))
+		}
+	}
+	if ic.SekaidSource != "" {
+		if err := check(v.Source, ic.SekaidSource); err != nil {
+			errs = append(errs, fmt.Sprintf("sekaid_source: %v", err))
+		}
+	}
+	if err := check(v.Derivation, ic.Derivation); err != nil {
+		errs = append(errs, fmt.Sprintf("derivation: %v", err))
+	}
+	if ab, err := BindingOf(ic); err != nil {
+		errs = append(errs, fmt.Sprintf("addresses: %v", err))
+	} else if err := ab.Validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("addresses: %v", err))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// CheckInstanceHome reports an instance whose home folder is gone. It is kept out
+// of ValidateInstanceConfig so that such an instance can still be listed and removed.
+func CheckInstanceHome(ic types.InstanceConfig) error {
+	st, err := os.Stat(ic.Home)
+	if err != nil {
+		return fmt.Errorf("home %s: %w", ic.Home, err)
+	}
+	if !st.IsDir() {
+		return fmt.Errorf("home %s is not a directory", ic.Home)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cfg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+func TestLoadConfigMissingHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c, err := DefaultCfg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab, err := AddressBindingForRange(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic := types.InstanceConfig{
+		Name:      "gone",
+		Home:      filepath.Join(c.Home, INSTANCES_FOLDER_NAME, "gone"),
+		Addresses: ab.Record(),
+	}
+	c.Instances = append(c.Instances, ic)
+	path, err := GenerateConfigFile(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadConfig(path, Validators{})
+	if err != nil {
+		t.Fatalf("an instance without home must not fail the load: %v", err)
+	}
+	if len(loaded.Instances) != 1 {
+		t.Fatalf("loaded %d instances", len(loaded.Instances))
+	}
+	if err := CheckInstanceHome(loaded.Instances[0]); err == nil {
+		t.Fatal("missing home not reported")
+	}
+
+	if err := os.MkdirAll(ic.Home, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckInstanceHome(loaded.Instances[0]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateInstanceConfigUsesValidators(t *testing.T) {
+	ab, err := AddressBindingForRange(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic := types.InstanceConfig{
+		Name:            "node",
+		Home:            "/nonexistent",
+		Addresses:       ab.Record(),
+		SekaidVersion:   "latest",
+		ResolvedVersion: "v0.4.1",
+		SekaidSource:    "github:KiraCore/sekai",
+	}
+	if err := ValidateInstanceConfig(ic, Validators{}); err != nil {
+		t.Fatalf("without checks: %v", err)
+	}
+
+	reject := func(string) error { return errors.New("rejected") }
+	err = ValidateInstanceConfig(ic, Validators{VersionSpec: reject, Version: reject, Source: reject})
+	if err == nil {
+		t.Fatal("injected checks were not run")
+	}
+	for _, field := range []string{"sekaid_version", "resolved_version", "sekaid_source"} {
+		if !strings.Contains(err.Error(), field+": rejected") {
+			t.Errorf("%s not checked: %v", field, err)
+		}
+	}
+}
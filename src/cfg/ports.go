@@ -0,0 +1,117 @@
+package cfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+// PORT_RANGE_STEP is the distance between the port blocks of two neighbouring ranges.
+// Range N shifts every default port by N*PORT_RANGE_STEP.
+// Ranges can still overlap where two default ports differ by a multiple of the step,
+// e.g. pprof 6060 of range 206 is prometheus 26660 of range 0; such ranges are
+// skipped when a range is allocated, see FindPortCollisions.
+const PORT_RANGE_STEP int = 100
+
+// MaxPortRange returns the highest range whose ports still fit into 1..65535.
+func MaxPortRange() int {
+	pairs, _ := PortPairsList(DefaultAddressBinding())
+	highest := 0
+	for _, pp := range pairs {
+		if pp.Default > highest {
+			highest = pp.Default
+		}
+	}
+	return (65535 - highest) / PORT_RANGE_STEP
+}
+
+// AddressBindingForRange returns the default binding with every port shifted into block n.
+func AddressBindingForRange(n int) (AddressBinding, error) {
+	if n < 0 || n > MaxPortRange() {
+		return AddressBinding{}, fmt.Errorf("port range %d out of bounds (0..%d)", n, MaxPortRange())
+	}
+	ab := DefaultAddressBinding()
+	pairs, err := PortPairsList(ab)
+	if err != nil {
+		return AddressBinding{}, err
+	}
+	for _, pp := range pairs {
+		if err := ab.SetPort(pp.Name, pp.Default+n*PORT_RANGE_STEP); err != nil {
+			return AddressBinding{}, fmt.Errorf("%s: %w", pp.Name, err)
+		}
+	}
+	return ab, nil
+}
+
+// BindingOf returns the stored binding of an instance.
+// Instances without a stored binding fall back to the one derived from their PortRange.
+func BindingOf(ic types.InstanceConfig) (AddressBinding, error) {
+	if ic.Addresses != (types.AddressBinding{}) {
+		return AddressBinding(ic.Addresses), nil
+	}
+	return AddressBindingForRange(ic.PortRange)
+}
+
+// Record converts the binding into its persisted form.
+func (a AddressBinding) Record() types.AddressBinding {
+	return types.AddressBinding(a)
+}
+
+// PortCollision describes one port claimed by more than one listener.
+type PortCollision struct {
+	Port  int
+	Users []string // "<instance>/<port name>"
+}
+
+func (c PortCollision) String() string {
+	return fmt.Sprintf("port %d used by %s", c.Port, strings.Join(c.Users, ", "))
+}
+
+// FindPortCollisions checks the listening ports of all instances against each other.
+// The client "node" address is skipped as it points to the instance's own rpc listener.
+func FindPortCollisions(instances []types.InstanceConfig) ([]PortCollision, error) {
+	users := make(map[int][]string)
+	for _, ic := range instances {
+		ab, err := BindingOf(ic)
+		if err != nil {
+			return nil, fmt.Errorf("instance %q: %w", ic.Name, err)
+		}
+		pairs, err := PortPairsList(ab)
+		if err != nil {
+			return nil, fmt.Errorf("instance %q: %w", ic.Name, err)
+		}
+		for _, pp := range pairs {
+			if pp.Name == "node" {
+				continue
+			}
+			users[pp.Current] = append(users[pp.Current], ic.Name+"/"+pp.Name)
+		}
+	}
+
+	var out []PortCollision
+	for port, u := range users {
+		if len(u) > 1 {
+			out = append(out, PortCollision{Port: port, Users: u})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Port < out[j].Port })
+	return out, nil
+}
+
+// CheckPortCollisions returns an error listing every collision found by FindPortCollisions.
+func CheckPortCollisions(instances []types.InstanceConfig) error {
+	collisions, err := FindPortCollisions(instances)
+	if err != nil {
+		return err
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(collisions))
+	for _, c := range collisions {
+		lines = append(lines, c.String())
+	}
+	return fmt.Errorf("port collisions:\n  - %s", strings.Join(lines, "\n  - "))
+}
@@ -0,0 +1,44 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+func instanceInRange(t *testing.T, name string, n int) types.InstanceConfig {
+	t.Helper()
+	ab, err := AddressBindingForRange(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.InstanceConfig{Name: name, PortRange: n, Addresses: ab.Record()}
+}
+
+func TestPortRangesOverlap(t *testing.T) {
+	// pprof 6060 shifted by 206 steps is the default prometheus port 26660
+	collisions, err := FindPortCollisions([]types.InstanceConfig{
+		instanceInRange(t, "a", 0),
+		instanceInRange(t, "b", 206),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collisions) != 1 || collisions[0].Port != 26660 {
+		t.Fatalf("collisions %v", collisions)
+	}
+
+	// neighbouring ranges never overlap
+	for n := 0; n < MaxPortRange(); n++ {
+		collisions, err := FindPortCollisions([]types.InstanceConfig{
+			instanceInRange(t, "a", n),
+			instanceInRange(t, "b", n+1),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(collisions) != 0 {
+			t.Fatalf("ranges %d and %d: %v", n, n+1, collisions)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	SEKAID_APP_TOML    string = "app.toml"
+	SEKAID_CONFIG_TOML string = "config.toml"
+	SEKAID_CLIENT_TOML string = "client.toml"
+)
+
+// bindingKey ties an AddressBinding field to its place in the sekaid config files.
+// An empty table means the key lives at the top level of the file.
+type bindingKey struct {
+	name  string
+	file  string
+	table string
+	key   string
+	field func(*AddressBinding) *string
+}
+
+// bindingKeys follows the layout documented on the AddressBinding fields.
+var bindingKeys = []bindingKey{
+	{"api", SEKAID_APP_TOML, "api", "address", func(a *AddressBinding) *string { return &a.ApiAddress }},
+	{"rosetta", SEKAID_APP_TOML, "rosetta", "address", func(a *AddressBinding) *string { return &a.RossettaAddress }},
+	{"grpc", SEKAID_APP_TOML, "grpc", "address", func(a *AddressBinding) *string { return &a.GrpcAddress }},
+	{"grpc_web", SEKAID_APP_TOML, "grpc-web", "address", func(a *AddressBinding) *string { return &a.GrpcWebAddress }},
+
+	{"proxy_app", SEKAID_CONFIG_TOML, "", "proxy_app", func(a *AddressBinding) *string { return &a.ProxyApp }},
+	{"rpc", SEKAID_CONFIG_TOML, "rpc", "laddr", func(a *AddressBinding) *string { return &a.RpcLaddr }},
+	{"rpc_pprof", SEKAID_CONFIG_TOML, "rpc", "pprof_laddr", func(a *AddressBinding) *string { return &a.RpcPprofLaddr }},
+	{"p2p", SEKAID_CONFIG_TOML, "p2p", "laddr", func(a *AddressBinding) *string { return &a.P2PLaddr }},
+	{"prometheus", SEKAID_CONFIG_TOML, "instrumentation", "prometheus_listen_addr", func(a *AddressBinding) *string {
+		return &a.InstrumentationPrometheusListenAddr
+	}},
+
+	{"node", SEKAID_CLIENT_TOML, "", "node", func(a *AddressBinding) *string { return &a.Node }},
+}
+
+// TomlKey addresses a single key inside a TOML file. Table "" is the root table.
+type TomlKey struct {
+	Table string
+	Key   string
+}
+
+// WriteAddressBinding patches the address keys of app.toml, config.toml and client.toml
+// inside <sekaidHome>/config. Only the value of each key is replaced, comments, ordering
+// and unrelated settings are kept as they are. Missing keys are appended to their table.
+func WriteAddressBinding(sekaidHome string, ab AddressBinding) error {
+	if err := ab.Validate(); err != nil {
+		return err
+	}
+	perFile := map[string]map[TomlKey]string{}
+	for _, bk := range bindingKeys {
+		if perFile[bk.file] == nil {
+			perFile[bk.file] = map[TomlKey]string{}
+		}
+		perFile[bk.file][TomlKey{Table: bk.table, Key: bk.key}] = *bk.field(&ab)
+	}
+
+	configDir := filepath.Join(sekaidHome, "config")
+	for _, file := range []string{SEKAID_APP_TOML, SEKAID_CONFIG_TOML, SEKAID_CLIENT_TOML} {
+		if err := PatchTomlFile(filepath.Join(configDir, file), perFile[file]); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// ReadAddressBinding reads the address keys back from the sekaid config files.
+func ReadAddressBinding(sekaidHome string) (AddressBinding, error) {
+	var ab AddressBinding
+	configDir := filepath.Join(sekaidHome, "config")
+	docs := map[string]map[string]any{}
+
+	for _, bk := range bindingKeys {
+		doc, ok := docs[bk.file]
+		if !ok {
+			b, err := os.ReadFile(filepath.Join(configDir, bk.file))
+			if err != nil {
+				return AddressBinding{}, err
+			}
+			if err := toml.Unmarshal(b, &doc); err != nil {
+				return AddressBinding{}, fmt.Errorf("%s: %w", bk.file, err)
+			}
+			docs[bk.file] = doc
+		}
+
+		tbl := doc
+		if bk.table != "" {
+			tbl, _ = doc[bk.table].(map[string]any)
+		}
+		v, _ := tbl[bk.key].(string)
+		*bk.field(&ab) = v
+	}
+	return ab, nil
+}
+
+// BindingDrift lists every address where got differs from want, keyed by port name.
+func BindingDrift(want, got AddressBinding) []string {
+	var out []string
+	for _, bk := range bindingKeys {
+		w, g := *bk.field(&want), *bk.field(&got)
+		if w != g {
+			out = append(out, fmt.Sprintf("%s (%s:[%s]:%s): want %q, got %q", bk.name, bk.file, bk.table, bk.key, w, g))
+		}
+	}
+	return out
+}
+
+// PatchTomlFile applies PatchToml to a file in place, keeping its permissions.
+func PatchTomlFile(path string, values map[TomlKey]string) error {
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	out, err := PatchToml(b, values)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(out, b) {
+		return nil
+	}
+
+	return writeFileAtomic(path, out, st.Mode().Perm())
+}
+
+// writeFileAtomic replaces path through a temporary file created with perm, so
+// neither a failed write nor a stale temporary file decides the final content or mode.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.partial")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// PatchToml sets string values in a TOML document without re-encoding it.
+// Lines of keys that are not patched stay byte-for-byte identical, including trailing comments.
+func PatchToml(doc []byte, values map[TomlKey]string) ([]byte, error) {
+	lines := strings.SplitAfter(string(doc), "\n")
+	pending := make(map[TomlKey]string, len(values))
+	for k, v := range values {
+		pending[k] = v
+	}
+
+	// lastLine remembers where each table ends so missing keys can be appended there.
+	lastLine := map[string]int{"": -1}
+	table := ""
+	// value tracks arrays and strings that continue on the next lines
+	var value valueScanner
+	for i, line := range lines {
+		if value.open() {
+			lastLine[table] = i
+			value.scan(line)
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "[["):
+			// arrays of tables are never patched
+			table = "\x00"
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			end := strings.Index(trimmed, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: malformed table header", i+1)
+			}
+			table = strings.TrimSpace(trimmed[1:end])
+			lastLine[table] = i
+			continue
+		}
+
+		lastLine[table] = i
+		key, valueStart, ok := splitKeyLine(line)
+		if !ok {
+			continue
+		}
+		value.scan(line[valueStart:])
+		tk := TomlKey{Table: table, Key: key}
+		v, ok := pending[tk]
+		if !ok {
+			continue
+		}
+		if value.open() {
+			return nil, fmt.Errorf("line %d: multi-line value of %s can not be patched", i+1, key)
+		}
+		rest, err := afterValue(line[valueStart:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		lines[i] = line[:valueStart] + strconv.Quote(v) + rest
+		delete(pending, tk)
+	}
+
+	if len(pending) == 0 {
+		return []byte(strings.Join(lines, "")), nil
+	}
+
+	// Append keys that were not found, in a stable order. Keys of existing tables go
+	// right after the table's last line, unknown tables are created at the end of the file.
+	inserts := map[int][]string{}
+	var newTables []string
+	newEntries := map[string][]string{}
+	for _, tk := range orderedKeys(pending) {
+		entry := fmt.Sprintf("%s = %s\n", tk.Key, strconv.Quote(pending[tk]))
+		if at, ok := lastLine[tk.Table]; ok {
+			inserts[at] = append(inserts[at], entry)
+			continue
+		}
+		if _, ok := newEntries[tk.Table]; !ok {
+			newTables = append(newTables, tk.Table)
+		}
+		newEntries[tk.Table] = append(newEntries[tk.Table], entry)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.Join(inserts[-1], ""))
+	for i, line := range lines {
+		sb.WriteString(line)
+		if len(inserts[i]) > 0 {
+			if !strings.HasSuffix(line, "\n") {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(strings.Join(inserts[i], ""))
+		}
+	}
+	for _, t := range newTables {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "\n[%s]\n%s", t, strings.Join(newEntries[t], ""))
+	}
+	return []byte(sb.String()), nil
+}
+
+func orderedKeys(m map[TomlKey]string) []TomlKey {
+	var out []TomlKey
+	// bindingKeys order first, anything else afterwards
+	for _, bk := range bindingKeys {
+		tk := TomlKey{Table: bk.table, Key: bk.key}
+		if _, ok := m[tk]; ok {
+			out = append(out, tk)
+		}
+	}
+	for tk := range m {
+		found := false
+		for _, o := range out {
+			if o == tk {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, tk)
+		}
+	}
+	return out
+}
+
+// splitKeyLine parses `key = value` and returns the bare key and the offset of the value.
+func splitKeyLine(line string) (string, int, bool) {
+	eq := strings.Index(line, "=")
+	if eq < 0 {
+		return "", 0, false
+	}
+	key := strings.TrimSpace(line[:eq])
+	if key == "" || strings.ContainsAny(key, ".\"' \t") {
+		return "", 0, false
+	}
+	start := eq + 1
+	for start < len(line) && (line[start] == ' ' || line[start] == '\t') {
+		start++
+	}
+	return key, start, true
+}
+
+// valueScanner follows a TOML value over several lines: the nesting of arrays and
+// inline tables and whether a multi-line string is open.
+type valueScanner struct {
+	depth int
+	// str is the delimiter of an open multi-line string
+	str string
+}
+
+func (vs *valueScanner) open() bool { return vs.depth > 0 || vs.str != "" }
+
+// scan advances the state over one line of a value.
+func (vs *valueScanner) scan(s string) {
+	for i := 0; i < len(s); i++ {
+		if vs.str != "" {
+			switch {
+			case vs.str == `"""` && s[i] == '\\':
+				i++
+			case strings.HasPrefix(s[i:], vs.str):
+				i += len(vs.str) - 1
+				vs.str = ""
+			}
+			continue
+		}
+		switch s[i] {
+		case '#':
+			return
+		case '"', '\'':
+			q := s[i : i+1]
+			if strings.HasPrefix(s[i:], q+q+q) {
+				vs.str = q + q + q
+				i += 2
+				continue
+			}
+			// a single-line string ends on this line
+			for i++; i < len(s) && s[i:i+1] != q; i++ {
+				if q == `"` && s[i] == '\\' {
+					i++
+				}
+			}
+		case '[', '{':
+			vs.depth++
+		case ']', '}':
+			if vs.depth > 0 {
+				vs.depth--
+			}
+		}
+	}
+}
+
+// afterValue skips the TOML value at the start of s and returns what follows it
+// (whitespace, a comment and the line ending).
+func afterValue(s string) (string, error) {
+	if s == "" {
+		return "", errors.New("missing value")
+	}
+	switch s[0] {
+	case '"':
+		if strings.HasPrefix(s, `"""`) {
+			return "", errors.New("multi-line strings are not supported")
+		}
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				return s[i+1:], nil
+			}
+		}
+		return "", errors.New("unterminated string")
+	case '\'':
+		if strings.HasPrefix(s, `'''`) {
+			return "", errors.New("multi-line strings are not supported")
+		}
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated string")
+		}
+		return s[end+2:], nil
+	default:
+		end := len(s)
+		if i := strings.IndexByte(s, '#'); i >= 0 {
+			end = i
+		}
+		value := strings.TrimRight(s[:end], " \t\r\n")
+		return s[len(value):], nil
+	}
+}
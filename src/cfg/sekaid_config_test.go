@@ -0,0 +1,241 @@
+package cfg
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func testBinding() AddressBinding {
+	return NewAddressBinding(
+		WithAPIAddress("tcp://0.0.0.0:11317"),
+		WithRossettaAddress(":18080"),
+		WithGRPCAddress("0.0.0.0:19090"),
+		WithGRPCWebAddress("0.0.0.0:19091"),
+		WithProxyApp("tcp://127.0.0.1:36658"),
+		WithRPCAndNode("tcp://127.0.0.1:36657"),
+		WithRPCPprof("localhost:16060"),
+		WithP2P("tcp://0.0.0.0:36656"),
+		WithPrometheus(":36660"),
+	)
+}
+
+// sekaidHome copies the config files sekaid generated into a fresh home.
+func sekaidHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{SEKAID_APP_TOML, SEKAID_CONFIG_TOML, SEKAID_CLIENT_TOML} {
+		b, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(home, "config", file), b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return home
+}
+
+func TestWriteAddressBindingGolden(t *testing.T) {
+	home := sekaidHome(t)
+	ab := testBinding()
+	if err := WriteAddressBinding(home, ab); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{SEKAID_APP_TOML, SEKAID_CONFIG_TOML, SEKAID_CLIENT_TOML} {
+		path := filepath.Join(home, "config", file)
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden := filepath.Join("testdata", file+".golden")
+		if *update {
+			if err := os.WriteFile(golden, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from %s:\n%s", file, golden, got)
+		}
+
+		var doc map[string]any
+		if err := toml.Unmarshal(got, &doc); err != nil {
+			t.Errorf("%s is no longer valid TOML: %v", file, err)
+		}
+		st, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Mode().Perm() != 0o600 {
+			t.Errorf("%s mode %o, want the original 600", file, st.Mode().Perm())
+		}
+	}
+
+	read, err := ReadAddressBinding(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != ab {
+		t.Errorf("read back %+v, want %+v", read, ab)
+	}
+	if drift := BindingDrift(ab, read); len(drift) != 0 {
+		t.Errorf("unexpected drift: %v", drift)
+	}
+
+	// a second write changes nothing
+	if err := WriteAddressBinding(home, ab); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{SEKAID_APP_TOML, SEKAID_CONFIG_TOML, SEKAID_CLIENT_TOML} {
+		got, _ := os.ReadFile(filepath.Join(home, "config", file))
+		want, _ := os.ReadFile(filepath.Join("testdata", file+".golden"))
+		if !bytes.Equal(got, want) {
+			t.Errorf("rewriting %s is not idempotent", file)
+		}
+	}
+}
+
+func TestReadAddressBindingDefaults(t *testing.T) {
+	home := sekaidHome(t)
+	ab, err := ReadAddressBinding(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ab.ApiAddress != "tcp://0.0.0.0:1317" || ab.GrpcWebAddress != "0.0.0.0:9091" || ab.Node != "tcp://localhost:26657" {
+		t.Errorf("unexpected binding %+v", ab)
+	}
+}
+
+func TestBindingDrift(t *testing.T) {
+	want := testBinding()
+	got := want
+	got.P2PLaddr = "tcp://0.0.0.0:26656"
+	got.Node = ""
+
+	drift := BindingDrift(want, got)
+	if len(drift) != 2 {
+		t.Fatalf("got %d drifts: %v", len(drift), drift)
+	}
+	if !strings.HasPrefix(drift[0], "p2p (config.toml:[p2p]:laddr)") || !strings.Contains(drift[0], `got "tcp://0.0.0.0:26656"`) {
+		t.Errorf("unexpected p2p drift %q", drift[0])
+	}
+	if !strings.HasPrefix(drift[1], "node (client.toml:[]:node)") {
+		t.Errorf("unexpected node drift %q", drift[1])
+	}
+}
+
+func TestPatchToml(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		values  map[TomlKey]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "keeps comments and quoting of other keys",
+			doc:    "a = 'x' # note\n[t]\nb = \"y\"   # keep\nc = 3\n",
+			values: map[TomlKey]string{{"t", "b"}: "z"},
+			want:   "a = 'x' # note\n[t]\nb = \"z\"   # keep\nc = 3\n",
+		},
+		{
+			name:   "literal string value",
+			doc:    "[t]\nb = 'y' # keep\n",
+			values: map[TomlKey]string{{"t", "b"}: "z"},
+			want:   "[t]\nb = \"z\" # keep\n",
+		},
+		{
+			name:   "array continuation is no table header",
+			doc:    "[telemetry]\nglobal-labels = [\n  [\"chain_id\",\"x\"],\n  [\"address\", \"y\"],\n]\n\n[api]\naddress = \"a\"\n",
+			values: map[TomlKey]string{{"api", "address"}: "b", {"telemetry", "address"}: "c"},
+			want:   "[telemetry]\nglobal-labels = [\n  [\"chain_id\",\"x\"],\n  [\"address\", \"y\"],\n]\naddress = \"c\"\n\n[api]\naddress = \"b\"\n",
+		},
+		{
+			name:   "brackets and comments inside strings",
+			doc:    "[t]\nlist = [\n  \"]\", # ] [\n  '[x]',\n]\naddress = \"a\"\n",
+			values: map[TomlKey]string{{"t", "address"}: "b"},
+			want:   "[t]\nlist = [\n  \"]\", # ] [\n  '[x]',\n]\naddress = \"b\"\n",
+		},
+		{
+			name:   "multi-line basic string",
+			doc:    "[t]\ntext = \"\"\"\n[api]\naddress = \\\"x\\\"\n\"\"\"\naddress = \"a\"\n",
+			values: map[TomlKey]string{{"t", "address"}: "b", {"api", "address"}: "c"},
+			want:   "[t]\ntext = \"\"\"\n[api]\naddress = \\\"x\\\"\n\"\"\"\naddress = \"b\"\n\n[api]\naddress = \"c\"\n",
+		},
+		{
+			name:   "multi-line literal string",
+			doc:    "text = '''\n[rpc]\nladdr = x\n'''\n[rpc]\nladdr = \"a\"\n",
+			values: map[TomlKey]string{{"rpc", "laddr"}: "b"},
+			want:   "text = '''\n[rpc]\nladdr = x\n'''\n[rpc]\nladdr = \"b\"\n",
+		},
+		{
+			name:   "inline table inside an array",
+			doc:    "[t]\nm = [\n  { a = [1], b = \"}\" },\n]\n[u]\n",
+			values: map[TomlKey]string{{"t", "address"}: "x"},
+			want:   "[t]\nm = [\n  { a = [1], b = \"}\" },\n]\naddress = \"x\"\n[u]\n",
+		},
+		{
+			name:   "root key goes before the first table",
+			doc:    "[t]\nb = 1\n",
+			values: map[TomlKey]string{{"", "node"}: "x"},
+			want:   "node = \"x\"\n[t]\nb = 1\n",
+		},
+		{
+			name:   "missing table is appended",
+			doc:    "a = 1",
+			values: map[TomlKey]string{{"rpc", "laddr"}: "x"},
+			want:   "a = 1\n\n[rpc]\nladdr = \"x\"\n",
+		},
+		{
+			name:   "arrays of tables are left alone",
+			doc:    "[[t]]\naddress = \"a\"\n[t2]\n",
+			values: map[TomlKey]string{{"t2", "address"}: "b"},
+			want:   "[[t]]\naddress = \"a\"\n[t2]\naddress = \"b\"\n",
+		},
+		{
+			name:    "multi-line value can not be patched",
+			doc:     "[t]\naddress = [\n  \"a\",\n]\n",
+			values:  map[TomlKey]string{{"t", "address"}: "b"},
+			wantErr: true,
+		},
+		{
+			name:    "malformed header",
+			doc:     "[t\n",
+			values:  map[TomlKey]string{{"t", "address"}: "b"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PatchToml([]byte(tt.doc), tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			var doc map[string]any
+			if err := toml.Unmarshal(got, &doc); err != nil {
+				t.Errorf("result is not valid TOML: %v", err)
+			}
+		})
+	}
+}
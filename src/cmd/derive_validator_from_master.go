@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
 	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
 	"github.com/PeepoFrog/sekai_manager/src/types"
 	"github.com/spf13/cobra"
@@ -12,10 +15,15 @@ import (
 // newDeriveValidatorFromMasterCmd is a leaf under root.
 func newDeriveValidatorFromMasterCmd(app *types.ManagerConfig) *cobra.Command {
 	var (
-		mnemonic  string
-		path      string
-		prefix    string
-		outFolder string
+		mnemonic          mnemonicInput
+		path              string
+		prefix            string
+		outFolder         string
+		passphraseFile    string
+		insecurePlaintext bool
+		shareFiles        []string
+		derive            string
+		fromInstance      string
 	)
 
 	cmd := &cobra.Command{
@@ -23,8 +31,8 @@ func newDeriveValidatorFromMasterCmd(app *types.ManagerConfig) *cobra.Command {
 		Aliases: []string{"derive-validator-from-master"},
 		Short:   "Derive a validator from a master key",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if mnemonic == "" {
-				return fmt.Errorf("mnemonic cannot be empty (use --mnemonic or -m)")
+			if mnemonic.given() && len(shareFiles) > 0 {
+				return fmt.Errorf("use either a mnemonic or --share-file, not both")
 			}
 			if outFolder == "" {
 				return fmt.Errorf("out folder is required (use --out or -o)")
@@ -32,23 +40,78 @@ func newDeriveValidatorFromMasterCmd(app *types.ManagerConfig) *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return deriveMnemonicFromMaster(mnemonic, prefix, path, outFolder)
+			opts := mnemonicderiver.DeliverOptions{InsecurePlaintext: insecurePlaintext}
+			if fromInstance != "" {
+				ic, err := instancesmanager.NewInstanceManagerFromConfig(app).GetInstance(fromInstance)
+				if err != nil {
+					return err
+				}
+				if ic.Derivation.Scheme == "" {
+					return fmt.Errorf("instance %s has no recorded key derivation", fromInstance)
+				}
+				opts.Derivation = mnemonicderiver.Derivation(ic.Derivation)
+				if !cmd.Flags().Changed("prefix") && opts.Derivation.Prefix != "" {
+					prefix = opts.Derivation.Prefix
+				}
+				if !cmd.Flags().Changed("path") && opts.Derivation.Path != "" {
+					path = opts.Derivation.Path
+				}
+			} else {
+				d, err := mnemonicderiver.ParseDerivation(derive)
+				if err != nil {
+					return err
+				}
+				opts.Derivation = d
+			}
+			// Take the secret before the passphrase, so prompts come in that order.
+			var (
+				master string
+				shares []string
+				err    error
+			)
+			if len(shareFiles) > 0 {
+				shares, err = readShareFiles(shareFiles)
+			} else {
+				master, err = mnemonic.read(cmd, true)
+			}
+			if err != nil {
+				return err
+			}
+			if insecurePlaintext {
+				fmt.Fprintln(os.Stderr, "WARNING: writing the derived mnemonics in cleartext")
+			} else if opts.Passphrase, err = readPassphrase(passphraseFile, true); err != nil {
+				return err
+			}
+
+			var setFile string
+			if len(shareFiles) > 0 {
+				setFile, err = mnemonicderiver.DeliverMnemonicKeysFromShares(shares, prefix, path, outFolder, opts)
+			} else {
+				setFile, err = mnemonicderiver.DeliverMnemonicKeysFromMaster(master, prefix, path, outFolder, opts)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("keys (%s) written to %s\n", opts.Derivation, filepath.Join(outFolder, "config"))
+			fmt.Println("mnemonic set written to", setFile)
+			return nil
 		},
 	}
 
 	// ---- flags ----
-	cmd.Flags().StringVarP(&mnemonic, "mnemonic", "m", "", "BIP39 mnemonic (REQUIRED)")
+	mnemonic.addFlags(cmd, "master BIP39 mnemonic")
 	cmd.Flags().StringVarP(&path, "path", "p", vlg.DefaultPath, "Derivation path (BIP44-style)")
 	cmd.Flags().StringVarP(&prefix, "prefix", "x", vlg.DefaultPrefix, "Derivation prefix (BIP44-style)")
 	cmd.Flags().StringVarP(&outFolder, "out", "o", "", "Output directory (REQUIRED)")
+	cmd.Flags().StringVar(&derive, "derive", mnemonicderiver.DERIVATION_MASTER, "Key set to derive: master, index:<n> or name:<name>")
+	cmd.Flags().StringVar(&fromInstance, "from-instance", "", "Reproduce the keys of this instance using its recorded derivation")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File holding the keystore passphrase")
+	cmd.Flags().StringArrayVar(&shareFiles, "share-file", nil, "File holding master shares from mnemonic split (repeatable, one share per line)")
+	cmd.Flags().BoolVar(&insecurePlaintext, "insecure-plaintext", false, "Write the derived mnemonics unencrypted to "+mnemonicderiver.PLAINTEXT_SET_FILE_NAME)
 
 	// Optional UX sugar
-	_ = cmd.MarkFlagRequired("mnemonic")
+	cmd.MarkFlagsMutuallyExclusive("derive", "from-instance")
 	_ = cmd.MarkFlagRequired("out")
 
 	return cmd
 }
-
-func deriveMnemonicFromMaster(masterMnemonic, prefix, path, outFolder string) error {
-	return mnemonicderiver.DeliverMnemonicKeysFromMaster(masterMnemonic, prefix, path, outFolder)
-}
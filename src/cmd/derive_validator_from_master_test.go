@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+const testMaster = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+
+// deriveKeys runs deriveValidatorFromMaster into a new folder and returns the
+// key set it wrote.
+func deriveKeys(t *testing.T, app *types.ManagerConfig, args ...string) *mnemonicderiver.KeySet {
+	t.Helper()
+	out := t.TempDir()
+	cmd := newDeriveValidatorFromMasterCmd(app)
+	cmd.SetArgs(append([]string{"--out", out}, args...))
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	set, err := mnemonicderiver.ReadKeystore(filepath.Join(out, mnemonicderiver.KEYSTORE_FILE_NAME), []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+func TestDeriveFromInstance(t *testing.T) {
+	t.Setenv(MNEMONIC_ENV, testMaster)
+	t.Setenv(PASSPHRASE_ENV, "passphrase")
+
+	recorded := types.KeyDerivation{Scheme: mnemonicderiver.DERIVATION_NAME, Name: "validator-1", Prefix: vlg.DefaultPrefix, Path: vlg.DefaultPath}
+	app := &types.ManagerConfig{Instances: []types.InstanceConfig{
+		{Name: "validator-1", Derivation: recorded},
+		{Name: "legacy"},
+	}}
+
+	want, err := mnemonicderiver.GenerateMnemonicsForDerivation(testMaster, mnemonicderiver.Derivation(recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := deriveKeys(t, app, "--from-instance", "validator-1")
+	if !reflect.DeepEqual(got, mnemonicderiver.NewKeySet(want)) {
+		t.Error("--from-instance does not reproduce the recorded derivation")
+	}
+	if byName := deriveKeys(t, app, "--derive", "name:validator-1"); !reflect.DeepEqual(byName, got) {
+		t.Error("--from-instance differs from --derive with the recorded name")
+	}
+	if master := deriveKeys(t, app); reflect.DeepEqual(master, got) {
+		t.Error("--from-instance derived the master's own set")
+	}
+
+	cmd := newDeriveValidatorFromMasterCmd(app)
+	cmd.SetArgs([]string{"--out", t.TempDir(), "--from-instance", "legacy"})
+	cmd.SilenceUsage = true
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "no recorded key derivation") {
+		t.Errorf("instance without a derivation: %v", err)
+	}
+}
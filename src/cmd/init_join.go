@@ -1,21 +1,72 @@
 package cmd
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"os"
+	"path/filepath"
 
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
+	initpkg "github.com/PeepoFrog/sekai_manager/src/instances_manager/init"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer"
 	"github.com/PeepoFrog/sekai_manager/src/types"
 	"github.com/spf13/cobra"
 )
 
 // newJoinCmd is a leaf under init.
 func newJoinCmd(app *types.ManagerConfig) *cobra.Command {
-	return &cobra.Command{
+	var (
+		opts     initpkg.JoinOptions
+		mnemonic mnemonicInput
+	)
+
+	cmd := &cobra.Command{
 		Use:   "join",
-		Short: "Join an existing setup",
+		Short: "Join an existing network through a trusted node",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: implement join logic
-			fmt.Println("init join: not implemented yet")
+			var err error
+			if opts.MasterMnemonic, err = mnemonic.read(cmd, false); err != nil {
+				return err
+			}
+			if opts.InsecureSkipGenesisVerify && opts.GenesisChecksum == "" && opts.Interx == "" {
+				fmt.Fprintln(os.Stderr, "WARNING: genesis.json is taken from the trusted node without verification")
+			}
+			im := instancesmanager.NewInstanceManagerFromConfig(app)
+			opts.Progress = newProgressPrinter()
+			ic, err := initpkg.InitJoin(cmd.Context(), im, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("instance %q joined %s, home %s\n", ic.Name, ic.ChainID, ic.Home)
+			if genesis, err := os.ReadFile(filepath.Join(ic.Home, "config", "genesis.json")); err == nil {
+				fmt.Printf("genesis sha256: %x\n", sha256.Sum256(genesis))
+			}
 			return nil
 		},
 	}
+
+	// ---- flags ----
+	cmd.Flags().StringVarP(&opts.Name, "name", "n", "", "Instance name (REQUIRED)")
+	cmd.Flags().StringVar(&opts.Moniker, "moniker", "", "Node moniker (defaults to the instance name)")
+	cmd.Flags().StringVar(&opts.SekaidVersion, "sekaid-version", "", "sekaid version: tag, latest, latest-rc or constraint like ~0.4.0 (exact for url/local sources, optional for build)")
+	cmd.Flags().StringVar(&opts.SekaidSource, "source", installer.DefaultSource, "sekaid source: github:<owner>/<repo>, url:<url>#sha256=<hex>, local:<path> or build:<checkout>")
+	cmd.Flags().BoolVar(&opts.IncludePrerelease, "prerelease", false, "Let the version constraint match prereleases")
+	cmd.Flags().BoolVar(&opts.InsecureSkipVerify, "insecure-skip-verify", false, "Install sekaid even if the release publishes no checksum")
+	cmd.Flags().StringVar(&opts.TrustedRPC, "rpc", "", "Tendermint RPC of the trusted node, e.g. http://1.2.3.4:26657 (REQUIRED)")
+	cmd.Flags().StringVar(&opts.Interx, "interx", "", "Interx of the trusted node, used to verify the genesis checksum")
+	cmd.Flags().StringVar(&opts.GenesisChecksum, "genesis-checksum", "", "Expected sha256 of genesis.json")
+	cmd.Flags().BoolVar(&opts.InsecureSkipGenesisVerify, "insecure-skip-genesis-verify", false, "Join without --genesis-checksum or --interx, trusting genesis.json as served by --rpc")
+	cmd.Flags().BoolVar(&opts.Seed, "seed", false, "Use the trusted node as seed instead of persistent peer")
+	cmd.Flags().StringSliceVar(&opts.ExtraPeers, "peers", nil, "Additional persistent peers (id@host:port)")
+	mnemonic.addFlags(cmd, "master BIP39 mnemonic to derive the node keys from (optional)")
+	cmd.Flags().StringVarP(&opts.Path, "path", "p", vlg.DefaultPath, "Derivation path (BIP44-style)")
+	cmd.Flags().StringVarP(&opts.Prefix, "prefix", "x", vlg.DefaultPrefix, "Derivation prefix (BIP44-style)")
+	cmd.Flags().StringVar(&opts.Derivation, "derive", "", "Key set to derive from the master (default master): master, index:<n>, name:<name> or instance (by instance name)")
+	cmd.Flags().BoolVarP(&opts.Verbose, "verbose", "v", false, "Print installer details")
+
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("rpc")
+
+	return cmd
 }
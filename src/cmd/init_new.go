@@ -3,19 +3,58 @@ package cmd
 import (
 	"fmt"
 
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
+	initpkg "github.com/PeepoFrog/sekai_manager/src/instances_manager/init"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer"
+	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
 	"github.com/PeepoFrog/sekai_manager/src/types"
 	"github.com/spf13/cobra"
 )
 
 // newNewCmd is a leaf under init.
 func newNewCmd(app *types.ManagerConfig) *cobra.Command {
-	return &cobra.Command{
+	var (
+		opts     initpkg.NewOptions
+		mnemonic mnemonicInput
+	)
+
+	cmd := &cobra.Command{
 		Use:   "new",
-		Short: "Create a new setup",
+		Short: "Create a new single-validator chain from a master mnemonic",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: implement new logic
-			fmt.Println("init new: not implemented yet")
+			var err error
+			if opts.MasterMnemonic, err = mnemonic.read(cmd, true); err != nil {
+				return err
+			}
+			im := instancesmanager.NewInstanceManagerFromConfig(app)
+			opts.Progress = newProgressPrinter()
+			ic, err := initpkg.InitNew(cmd.Context(), im, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("instance %q initialized: chain-id %s, home %s\n", ic.Name, ic.ChainID, ic.Home)
 			return nil
 		},
 	}
+
+	// ---- flags ----
+	cmd.Flags().StringVarP(&opts.Name, "name", "n", "", "Instance name (REQUIRED)")
+	cmd.Flags().StringVar(&opts.ChainID, "chain-id", "", "Chain ID of the new network (REQUIRED)")
+	cmd.Flags().StringVar(&opts.Moniker, "moniker", "", "Validator moniker (defaults to the instance name)")
+	cmd.Flags().StringVar(&opts.SekaidVersion, "sekaid-version", "", "sekaid version: tag, latest, latest-rc or constraint like ~0.4.0 (exact for url/local sources, optional for build)")
+	cmd.Flags().StringVar(&opts.SekaidSource, "source", installer.DefaultSource, "sekaid source: github:<owner>/<repo>, url:<url>#sha256=<hex>, local:<path> or build:<checkout>")
+	cmd.Flags().BoolVar(&opts.IncludePrerelease, "prerelease", false, "Let the version constraint match prereleases")
+	cmd.Flags().BoolVar(&opts.InsecureSkipVerify, "insecure-skip-verify", false, "Install sekaid even if the release publishes no checksum")
+	cmd.Flags().StringVar(&opts.GenesisCoins, "genesis-coins", initpkg.DEFAULT_GENESIS_COINS, "Coins granted to the validator and signer accounts")
+	mnemonic.addFlags(cmd, "master BIP39 mnemonic")
+	cmd.Flags().StringVarP(&opts.Path, "path", "p", vlg.DefaultPath, "Derivation path (BIP44-style)")
+	cmd.Flags().StringVarP(&opts.Prefix, "prefix", "x", vlg.DefaultPrefix, "Derivation prefix (BIP44-style)")
+	cmd.Flags().StringVar(&opts.Derivation, "derive", mnemonicderiver.DERIVATION_MASTER, "Key set to derive from the master: master, index:<n>, name:<name> or instance (by instance name)")
+	cmd.Flags().BoolVarP(&opts.Verbose, "verbose", "v", false, "Print installer details")
+
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("chain-id")
+
+	return cmd
 }
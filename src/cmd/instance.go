@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/supervisor"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+	"github.com/spf13/cobra"
+)
+
+// newInstanceCmd returns the "instance" parent command and adds its leaf subcommands.
+func newInstanceCmd(app *types.ManagerConfig) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "instance",
+		Short: "Manage registered instances",
+	}
+
+	c.AddCommand(newInstanceRemoveCmd(app))
+	return c
+}
+
+// newInstanceRemoveCmd is a leaf under instance.
+func newInstanceRemoveCmd(app *types.ManagerConfig) *cobra.Command {
+	var (
+		force          bool
+		timeout        time.Duration
+		passphraseFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "remove <instance>",
+		Aliases: []string{"rm"},
+		Short:   "Stop an instance, archive its keys encrypted and delete its data",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pass, err := readPassphrase(passphraseFile, true)
+			if err != nil {
+				return err
+			}
+			archive, err := instancesmanager.NewInstanceManagerFromConfig(app).RemoveInstance(cmd.Context(), args[0], instancesmanager.RemoveOptions{
+				Passphrase:  pass,
+				Force:       force,
+				StopTimeout: timeout,
+			})
+			if err != nil {
+				return err
+			}
+			if archive == "" {
+				fmt.Printf("%s removed (no key files to archive)\n", args[0])
+				return nil
+			}
+			fmt.Printf("%s removed, keys archived to %s\n", args[0], archive)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Remove even if the instance is an active validator")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", supervisor.DefaultStopTimeout, "Time to wait after SIGTERM before sending SIGKILL")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File holding the passphrase of the key archive")
+	return cmd
+}
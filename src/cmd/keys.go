@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+	"github.com/spf13/cobra"
+)
+
+// newKeysCmd returns the "keys" parent command and adds its leaf subcommands.
+func newKeysCmd(app *types.ManagerConfig) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "keys",
+		Short: "Inspect encrypted mnemonic keystores",
+	}
+
+	c.AddCommand(newKeysUnlockCmd(app))
+	c.AddCommand(newKeysExportCmd(app))
+	return c
+}
+
+// newKeysUnlockCmd is a leaf under keys.
+func newKeysUnlockCmd(app *types.ManagerConfig) *cobra.Command {
+	var (
+		passphraseFile string
+		path           string
+		prefix         string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "unlock <keystore>",
+		Short: "Decrypt a keystore and print the public addresses it holds",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			set, err := openKeystore(args[0], passphraseFile)
+			if err != nil {
+				return err
+			}
+			validatorAddr, err := mnemonicderiver.AccAddressFromMnemonic(set.ValAddrMnemonic, prefix, path)
+			if err != nil {
+				return err
+			}
+			signerAddr, err := mnemonicderiver.AccAddressFromMnemonic(set.SignerAddrMnemonic, prefix, path)
+			if err != nil {
+				return err
+			}
+			fmt.Println("validator address:", validatorAddr)
+			fmt.Println("signer address:   ", signerAddr)
+			fmt.Println("node id:          ", set.ValNodeID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File holding the keystore passphrase")
+	cmd.Flags().StringVarP(&path, "path", "p", vlg.DefaultPath, "Derivation path (BIP44-style)")
+	cmd.Flags().StringVarP(&prefix, "prefix", "x", vlg.DefaultPrefix, "Derivation prefix (BIP44-style)")
+	return cmd
+}
+
+// newKeysExportCmd is a leaf under keys.
+func newKeysExportCmd(app *types.ManagerConfig) *cobra.Command {
+	var (
+		passphraseFile string
+		out            string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export <keystore>",
+		Short: "Decrypt a keystore and print or write the mnemonic set in cleartext",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			set, err := openKeystore(args[0], passphraseFile)
+			if err != nil {
+				return err
+			}
+			if out == "" {
+				_, err = os.Stdout.Write(set.Encode())
+				return err
+			}
+			if err := mnemonicderiver.WritePlaintextSet(out, set); err != nil {
+				return fmt.Errorf("unable to write %s: %w", out, err)
+			}
+			fmt.Fprintln(os.Stderr, "WARNING: mnemonic set written in cleartext to", out)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File holding the keystore passphrase")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "Write the set to this file (mode 0600) instead of stdout")
+	return cmd
+}
+
+func openKeystore(path, passphraseFile string) (*mnemonicderiver.KeySet, error) {
+	pass, err := readPassphrase(passphraseFile, false)
+	if err != nil {
+		return nil, err
+	}
+	return mnemonicderiver.ReadKeystore(path, pass)
+}
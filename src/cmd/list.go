@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/supervisor"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+	"github.com/spf13/cobra"
+)
+
+// newListCmd is a leaf under root.
+func newListCmd(app *types.ManagerConfig) *cobra.Command {
+	var (
+		output string
+		name   string
+		states []string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List registered instances",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("unknown output format %q (use table or json)", output)
+			}
+			for _, s := range states {
+				switch supervisor.State(s) {
+				case supervisor.StateRunning, supervisor.StateStopped, supervisor.StateCrashed:
+				default:
+					return fmt.Errorf("unknown state %q (use running, stopped or crashed)", s)
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := instancesmanager.ListFilter{NameGlob: name}
+			for _, s := range states {
+				filter.States = append(filter.States, supervisor.State(s))
+			}
+			list, err := instancesmanager.NewInstanceManagerFromConfig(app).ListInstances(filter)
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(list)
+			}
+			return printListTable(list)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: table or json")
+	cmd.Flags().StringVar(&name, "name", "", "Only instances whose name matches this glob")
+	cmd.Flags().StringSliceVar(&states, "state", nil, "Only instances in these states (running, stopped, crashed)")
+	return cmd
+}
+
+func printListTable(list []instancesmanager.InstanceDescriptor) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tVERSION\tCHAIN ID\tPORTS\tHOME\tKEYS\tBINARY")
+	for _, d := range list {
+		ports := make([]string, 0, len(d.Ports))
+		for _, p := range d.Ports {
+			ports = append(ports, fmt.Sprintf("%s=%d", p.Name, p.Current))
+		}
+		home := d.Config.Home
+		if !d.HomeExists {
+			home += " (missing)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%t\t%s\n",
+			d.Config.Name, d.State, dash(d.Version), dash(d.Config.ChainID), strings.Join(ports, ","), home, d.KeysExist, dash(d.BinaryPath))
+	}
+	return w.Flush()
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
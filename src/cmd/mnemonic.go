@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/shamir"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+	"github.com/spf13/cobra"
+)
+
+// newMnemonicCmd returns the "mnemonic" parent command and adds its leaf subcommands.
+func newMnemonicCmd(app *types.ManagerConfig) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "mnemonic",
+		Short: "Create and handle master mnemonics",
+	}
+
+	c.AddCommand(newMnemonicGenerateCmd(app))
+	c.AddCommand(newMnemonicSplitCmd(app))
+	c.AddCommand(newMnemonicCombineCmd(app))
+	return c
+}
+
+// newMnemonicGenerateCmd is a leaf under mnemonic.
+func newMnemonicGenerateCmd(app *types.ManagerConfig) *cobra.Command {
+	var (
+		words      int
+		dice       string
+		hex        string
+		showSystem bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a new BIP39 master mnemonic",
+		Long: "Generate a new BIP39 master mnemonic from system randomness.\n" +
+			"Dice rolls or hex given with --dice/--hex are mixed in as extra entropy:\n" +
+			"the mnemonic entropy is the first 16 (12 words) or 32 (24 words) bytes of\n" +
+			"sha256(system || user), where user is the hex bytes or the dice digits as text\n" +
+			"without whitespace. --show-system-entropy prints the system part, so the mix\n" +
+			"can be recomputed offline.\n" +
+			"The printed fingerprint is the first 8 bytes of sha256 over the mnemonic entropy;\n" +
+			"it identifies the mnemonic, not the sources it was mixed from.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if dice != "" && hex != "" {
+				return fmt.Errorf("use either --dice or --hex, not both")
+			}
+			if showSystem && dice == "" && hex == "" {
+				return fmt.Errorf("--show-system-entropy needs --dice or --hex, without them the system entropy is the mnemonic entropy")
+			}
+			_, err := mnemonicderiver.EntropyBits(words)
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var user *mnemonicderiver.UserEntropy
+			var err error
+			switch {
+			case dice != "":
+				user, err = mnemonicderiver.ParseUserEntropy(mnemonicderiver.ENTROPY_DICE, dice)
+			case hex != "":
+				user, err = mnemonicderiver.ParseUserEntropy(mnemonicderiver.ENTROPY_HEX, hex)
+			}
+			if err != nil {
+				return err
+			}
+			if user != nil {
+				bits, _ := mnemonicderiver.EntropyBits(words)
+				if user.Bits < float64(bits) {
+					fmt.Fprintf(os.Stderr, "WARNING: user entropy holds about %.0f of %d bits; system randomness covers the rest\n", user.Bits, bits)
+				}
+			}
+
+			g, err := mnemonicderiver.GenerateMnemonic(words, user)
+			if err != nil {
+				return err
+			}
+			fmt.Println(g.Mnemonic)
+			fmt.Fprintln(os.Stderr, "entropy fingerprint:", g.Fingerprint)
+			if g.UserEntropyDigest != "" {
+				fmt.Fprintln(os.Stderr, "user entropy sha256:", g.UserEntropyDigest)
+			}
+			if showSystem {
+				fmt.Fprintln(os.Stderr, "WARNING: the system entropy and your dice or hex together reveal the mnemonic")
+				fmt.Fprintf(os.Stderr, "system entropy: %x\n", g.SystemEntropy)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&words, "words", "w", 24, "Number of words (12 or 24)")
+	cmd.Flags().StringVar(&dice, "dice", "", "Dice rolls (digits 1-6) to mix into the entropy")
+	cmd.Flags().StringVar(&hex, "hex", "", "Hex string to mix into the entropy")
+	cmd.Flags().BoolVar(&showSystem, "show-system-entropy", false, "Print the system entropy so the mix with --dice/--hex can be recomputed")
+	return cmd
+}
+
+// newMnemonicSplitCmd is a leaf under mnemonic.
+func newMnemonicSplitCmd(app *types.ManagerConfig) *cobra.Command {
+	var (
+		mnemonic  mnemonicInput
+		shares    int
+		threshold int
+		outDir    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "split",
+		Short: "Split a master mnemonic into Shamir word shares",
+		Long: "Split a master mnemonic into N word shares, any K of which rebuild it.\n" +
+			"Fewer than K shares reveal nothing about the master. K must be at least 2.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// checked before the mnemonic prompt; a 1-of-N share would be the master itself
+			if threshold < shamir.MIN_THRESHOLD || threshold > shares {
+				return fmt.Errorf("threshold must be between %d and the number of shares", shamir.MIN_THRESHOLD)
+			}
+			if shares > shamir.MAX_SHARES {
+				return fmt.Errorf("at most %d shares are supported", shamir.MAX_SHARES)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			master, err := mnemonic.read(cmd, true)
+			if err != nil {
+				return err
+			}
+			list, err := mnemonicderiver.SplitMnemonic(master, shares, threshold)
+			if err != nil {
+				return err
+			}
+			if outDir == "" {
+				for i, s := range list {
+					fmt.Printf("share %d of %d (threshold %d):\n%s\n\n", i+1, shares, threshold, s)
+				}
+				return nil
+			}
+			if err := os.MkdirAll(outDir, 0700); err != nil {
+				return err
+			}
+			for i, s := range list {
+				file := filepath.Join(outDir, fmt.Sprintf("share-%d-of-%d.txt", i+1, shares))
+				if err := mnemonicderiver.WriteShare(file, s); err != nil {
+					return fmt.Errorf("unable to write %s: %w", file, err)
+				}
+				fmt.Println("share written to", file)
+			}
+			return nil
+		},
+	}
+
+	mnemonic.addFlags(cmd, "master BIP39 mnemonic")
+	cmd.Flags().IntVarP(&shares, "shares", "n", 0, "Number of shares to create (REQUIRED)")
+	cmd.Flags().IntVarP(&threshold, "threshold", "k", 0, "Number of shares needed to rebuild the master, at least 2 (REQUIRED)")
+	cmd.Flags().StringVarP(&outDir, "out", "o", "", "Write each share to its own file (mode 0600) in this directory")
+	_ = cmd.MarkFlagRequired("shares")
+	_ = cmd.MarkFlagRequired("threshold")
+	return cmd
+}
+
+// newMnemonicCombineCmd is a leaf under mnemonic.
+func newMnemonicCombineCmd(app *types.ManagerConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "combine <share-file>...",
+		Short: "Rebuild and validate a master mnemonic from a quorum of shares",
+		Long: "Rebuild a master mnemonic from share files (one share per line).\n" +
+			"Use \"-\" to read shares from stdin. To derive keys without printing the master, pass\n" +
+			"the share files to deriveValidatorFromMaster --share-file instead.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shares, err := readShareFiles(args)
+			if err != nil {
+				return err
+			}
+			mnemonic, err := mnemonicderiver.CombineMnemonicShares(shares)
+			if err != nil {
+				return err
+			}
+			fingerprint, err := mnemonicderiver.EntropyFingerprint(mnemonic)
+			if err != nil {
+				return err
+			}
+			fmt.Println(mnemonic)
+			fmt.Fprintln(os.Stderr, "entropy fingerprint:", fingerprint)
+			return nil
+		},
+	}
+	return cmd
+}
+
+// readShareFiles returns the non-empty lines of the given files; "-" reads stdin.
+func readShareFiles(files []string) ([]string, error) {
+	var shares []string
+	for _, name := range files {
+		f := os.Stdin
+		if name != "-" {
+			var err error
+			if f, err = os.Open(name); err != nil {
+				return nil, err
+			}
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if line := strings.TrimSpace(sc.Text()); line != "" {
+				shares = append(shares, line)
+			}
+		}
+		err := sc.Err()
+		if name != "-" {
+			f.Close()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", name, err)
+		}
+	}
+	return shares, nil
+}
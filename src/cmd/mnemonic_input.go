@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	// MNEMONIC_ENV can hold the master mnemonic for non-interactive use.
+	MNEMONIC_ENV string = "SEKAI_MANAGER_MNEMONIC"
+	// STRICT_ENV sets the default of --strict, any value strconv.ParseBool accepts.
+	STRICT_ENV string = "SEKAI_MANAGER_STRICT"
+)
+
+// mnemonicInput collects the ways a command can be handed the master mnemonic.
+type mnemonicInput struct {
+	arg   string
+	file  string
+	stdin bool
+}
+
+// addFlags registers --mnemonic, --mnemonic-file and --mnemonic-stdin; only one may be used.
+func (in *mnemonicInput) addFlags(cmd *cobra.Command, what string) {
+	cmd.Flags().StringVarP(&in.arg, "mnemonic", "m", "", what+" (visible in shell history and ps, refused with --strict)")
+	cmd.Flags().StringVar(&in.file, "mnemonic-file", "", "File holding the "+what)
+	cmd.Flags().BoolVar(&in.stdin, "mnemonic-stdin", false, "Read the "+what+" from stdin")
+	cmd.MarkFlagsMutuallyExclusive("mnemonic", "mnemonic-file", "mnemonic-stdin")
+}
+
+// given reports whether one of the mnemonic flags was used.
+func (in *mnemonicInput) given() bool {
+	return in.arg != "" || in.file != "" || in.stdin
+}
+
+// read takes the mnemonic from --mnemonic, --mnemonic-file, --mnemonic-stdin,
+// MNEMONIC_ENV or a no-echo prompt asked twice, in that order. Without required
+// there is no prompt and an empty result means none was given.
+func (in *mnemonicInput) read(cmd *cobra.Command, required bool) (string, error) {
+	switch {
+	case in.arg != "":
+		if strict, _ := cmd.Flags().GetBool("strict"); strict {
+			return "", fmt.Errorf("--mnemonic is refused in strict mode (use --mnemonic-file, --mnemonic-stdin, %s or the prompt)", MNEMONIC_ENV)
+		}
+		fmt.Fprintln(os.Stderr, "WARNING: a mnemonic given with --mnemonic ends up in shell history and the process list")
+		return normalizeMnemonic(in.arg)
+	case in.file != "":
+		b, err := os.ReadFile(in.file)
+		if err != nil {
+			return "", err
+		}
+		return normalizeMnemonic(string(b))
+	case in.stdin:
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("unable to read mnemonic from stdin: %w", err)
+		}
+		return normalizeMnemonic(string(b))
+	case os.Getenv(MNEMONIC_ENV) != "":
+		return normalizeMnemonic(os.Getenv(MNEMONIC_ENV))
+	case !required:
+		return "", nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no mnemonic given (use --mnemonic-file, --mnemonic-stdin or %s)", MNEMONIC_ENV)
+	}
+	fmt.Fprint(os.Stderr, "Master mnemonic: ")
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	mnemonic, err := normalizeMnemonic(string(first))
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Repeat master mnemonic: ")
+	again, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if repeated, _ := normalizeMnemonic(string(again)); repeated != mnemonic {
+		return "", errors.New("mnemonics do not match")
+	}
+	return mnemonic, nil
+}
+
+// strictFromEnv reads the --strict default from STRICT_ENV; unset is false.
+func strictFromEnv() (bool, error) {
+	v := os.Getenv(STRICT_ENV)
+	if v == "" {
+		return false, nil
+	}
+	strict, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s=%q: want true or false", STRICT_ENV, v)
+	}
+	return strict, nil
+}
+
+// normalizeMnemonic collapses whitespace and line breaks to single spaces.
+func normalizeMnemonic(s string) (string, error) {
+	m := strings.Join(strings.Fields(s), " ")
+	if m == "" {
+		return "", errors.New("mnemonic is empty")
+	}
+	return m, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestReadOptionalMnemonicFromEnv(t *testing.T) {
+	cmd := &cobra.Command{}
+	var in mnemonicInput
+	in.addFlags(cmd, "mnemonic")
+
+	t.Setenv(MNEMONIC_ENV, "")
+	if m, err := in.read(cmd, false); err != nil || m != "" {
+		t.Fatalf("nothing given: %q, %v", m, err)
+	}
+
+	t.Setenv(MNEMONIC_ENV, "  abandon\n abandon  about ")
+	m, err := in.read(cmd, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != "abandon abandon about" {
+		t.Fatalf("optional read ignored %s: %q", MNEMONIC_ENV, m)
+	}
+}
+
+func TestStrictFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "", want: false},
+		{value: "0", want: false},
+		{value: "false", want: false},
+		{value: "1", want: true},
+		{value: "true", want: true},
+		{value: "yes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(STRICT_ENV, tt.value)
+		got, err := strictFromEnv()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s=%q: %t, %v", STRICT_ENV, tt.value, got, err)
+		}
+	}
+}
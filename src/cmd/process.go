@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/supervisor"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+	"github.com/spf13/cobra"
+)
+
+// newStartCmd is a leaf under root.
+func newStartCmd(app *types.ManagerConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "start <instance>",
+		Short: "Start a managed sekaid instance",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ic, err := instancesmanager.NewInstanceManagerFromConfig(app).GetInstance(args[0])
+			if err != nil {
+				return err
+			}
+			sv := supervisor.New(app.Home)
+			pid, err := sv.Start(ic)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s started (pid %d), logs: %s\n", ic.Name, pid, sv.StdoutLog(ic.Name))
+			return nil
+		},
+	}
+}
+
+// newStopCmd is a leaf under root.
+func newStopCmd(app *types.ManagerConfig) *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "stop <instance>",
+		Short: "Stop a managed sekaid instance (SIGTERM, then SIGKILL after --timeout)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ic, err := instancesmanager.NewInstanceManagerFromConfig(app).GetInstance(args[0])
+			if err != nil {
+				return err
+			}
+			if err := supervisor.New(app.Home).Stop(ic.Name, timeout); err != nil {
+				return err
+			}
+			fmt.Printf("%s stopped\n", ic.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", supervisor.DefaultStopTimeout, "Time to wait after SIGTERM before sending SIGKILL")
+	return cmd
+}
+
+// newRestartCmd is a leaf under root.
+func newRestartCmd(app *types.ManagerConfig) *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "restart <instance>",
+		Short: "Restart a managed sekaid instance",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ic, err := instancesmanager.NewInstanceManagerFromConfig(app).GetInstance(args[0])
+			if err != nil {
+				return err
+			}
+			pid, err := supervisor.New(app.Home).Restart(ic, timeout)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s restarted (pid %d)\n", ic.Name, pid)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", supervisor.DefaultStopTimeout, "Time to wait after SIGTERM before sending SIGKILL")
+	return cmd
+}
+
+// newKillCmd is a leaf under root.
+func newKillCmd(app *types.ManagerConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "kill <instance>",
+		Short: "Kill a managed sekaid instance with SIGKILL",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ic, err := instancesmanager.NewInstanceManagerFromConfig(app).GetInstance(args[0])
+			if err != nil {
+				return err
+			}
+			if err := supervisor.New(app.Home).Kill(ic.Name); err != nil {
+				return err
+			}
+			fmt.Printf("%s killed\n", ic.Name)
+			return nil
+		},
+	}
+}
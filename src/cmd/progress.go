@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/downloader"
+	"golang.org/x/term"
+)
+
+const progressBarWidth = 30
+
+// newProgressPrinter renders download progress on stderr. On a terminal the bar is
+// redrawn in place; otherwise only the final line is printed.
+func newProgressPrinter() func(downloader.Progress) {
+	var w io.Writer = os.Stderr
+	tty := term.IsTerminal(int(os.Stderr.Fd()))
+
+	return func(p downloader.Progress) {
+		if !tty && !p.Done {
+			return
+		}
+		line := formatProgress(p)
+		if tty {
+			fmt.Fprintf(w, "\r%s", line)
+		} else {
+			fmt.Fprint(w, line)
+		}
+		if p.Done {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func formatProgress(p downloader.Progress) string {
+	rate := humanBytes(int64(p.BytesPerSecond)) + "/s"
+	if p.Total <= 0 {
+		return fmt.Sprintf("downloading %s  %s", humanBytes(p.Downloaded), rate)
+	}
+	frac := float64(p.Downloaded) / float64(p.Total)
+	if frac > 1 {
+		frac = 1
+	}
+	filled := int(frac * progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	return fmt.Sprintf("[%s] %5.1f%%  %s / %s  %s", bar, frac*100, humanBytes(p.Downloaded), humanBytes(p.Total), rate)
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -1,22 +1,49 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/PeepoFrog/sekai_manager/src/cfg"
 	"github.com/PeepoFrog/sekai_manager/src/types"
 	"github.com/spf13/cobra"
 )
 
 // NewRootCmd constructs the root command and wires subcommands.
 func NewRootCmd(app *types.ManagerConfig) *cobra.Command {
+	strict, strictErr := strictFromEnv()
 	root := &cobra.Command{
 		Use:   "app",
 		Short: "CLI root command",
 		Long:  "An example CLI showing a subcommand tree with init/{join,new}, deriveValidatorFromMaster, and status.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if strictErr != nil {
+				return strictErr
+			}
+			for _, ic := range app.Instances {
+				if err := cfg.CheckInstanceHome(ic); err != nil {
+					fmt.Fprintf(os.Stderr, "WARNING: instance %q: %v\n", ic.Name, err)
+				}
+			}
+			return nil
+		},
 	}
 
+	root.PersistentFlags().Bool("strict", strict, "Refuse secrets passed as command line arguments (default from "+STRICT_ENV+")")
+
 	// Attach subcommands
 	root.AddCommand(newInitCmd(app))
 	root.AddCommand(newDeriveValidatorFromMasterCmd(app))
+	root.AddCommand(newKeysCmd(app))
+	root.AddCommand(newMnemonicCmd(app))
 	root.AddCommand(newStatusCmd(app))
+	root.AddCommand(newListCmd(app))
+	root.AddCommand(newInstanceCmd(app))
+	root.AddCommand(newStoreCmd(app))
+	root.AddCommand(newStartCmd(app))
+	root.AddCommand(newStopCmd(app))
+	root.AddCommand(newRestartCmd(app))
+	root.AddCommand(newKillCmd(app))
 
 	return root
 }
@@ -1,21 +1,63 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
 
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
 	"github.com/PeepoFrog/sekai_manager/src/types"
 	"github.com/spf13/cobra"
 )
 
 // newStatusCmd is a leaf under root.
 func newStatusCmd(app *types.ManagerConfig) *cobra.Command {
-	return &cobra.Command{
+	var (
+		output  string
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
 		Use:   "status",
-		Short: "Show current status (stub)",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: implement status logic
-			fmt.Println("status: OK (stub)")
+		Short: "Show process and chain status of every registered instance",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("unknown output format %q (use table or json)", output)
+			}
 			return nil
 		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statuses := instancesmanager.NewInstanceManagerFromConfig(app).Status(cmd.Context(), timeout)
+			if output == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(statuses)
+			}
+			return printStatusTable(statuses)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: table or json")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", instancesmanager.DefaultStatusTimeout, "Timeout of the RPC queries per instance")
+	return cmd
+}
+
+func printStatusTable(statuses []instancesmanager.InstanceStatus) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tHEIGHT\tBLOCK TIME\tCATCHING UP\tNODE ID\tPEERS\tVOTING POWER\tERROR")
+	for _, s := range statuses {
+		blockTime := "-"
+		if !s.BlockTime.IsZero() {
+			blockTime = s.BlockTime.UTC().Format(time.RFC3339)
+		}
+		nodeID := s.NodeID
+		if nodeID == "" {
+			nodeID = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%t\t%s\t%d\t%d\t%s\n",
+			s.Name, s.State, s.Height, blockTime, s.CatchingUp, nodeID, s.Peers, s.VotingPower, s.Error)
 	}
+	return w.Flush()
 }
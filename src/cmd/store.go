@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/store"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+	"github.com/spf13/cobra"
+)
+
+// newStoreCmd returns the "store" parent command and adds its leaf subcommands.
+func newStoreCmd(app *types.ManagerConfig) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "store",
+		Short: "Manage the shared sekaid binary store",
+	}
+
+	c.AddCommand(newStoreListCmd(app))
+	c.AddCommand(newStorePruneCmd(app))
+	c.AddCommand(newStorePinCmd(app, true))
+	c.AddCommand(newStorePinCmd(app, false))
+	return c
+}
+
+// newStoreListCmd is a leaf under store.
+func newStoreListCmd(app *types.ManagerConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List stored sekaid binaries and the instances using them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := store.New(app.Home).List()
+			if err != nil {
+				return err
+			}
+			users := storeUsers(app)
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tVERSION\tPLATFORM\tSOURCE\tPINNED\tINSTALLED\tUSED BY")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s-%s\t%s\t%t\t%s\t%v\n",
+					e.ID, e.Version, e.OS, e.Arch, dash(e.Source), e.Pinned, e.InstalledAt.Format(time.RFC3339), users[e.ID])
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// newStorePruneCmd is a leaf under store.
+func newStorePruneCmd(app *types.ManagerConfig) *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete stored binaries that are neither pinned, used by an instance nor handed out within the last hour",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			referenced := map[string]bool{}
+			for id := range storeUsers(app) {
+				referenced[id] = true
+			}
+			removed, err := store.New(app.Home).Prune(referenced, dryRun)
+			for _, e := range removed {
+				if dryRun {
+					fmt.Println("would remove", e.ID)
+				} else {
+					fmt.Println("removed", e.ID)
+				}
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only print what would be removed")
+	return cmd
+}
+
+// newStorePinCmd is a leaf under store; pin=false builds "unpin".
+func newStorePinCmd(app *types.ManagerConfig, pin bool) *cobra.Command {
+	use, short := "pin <id|version>", "Protect stored binaries from prune"
+	if !pin {
+		use, short = "unpin <id|version>", "Allow prune to remove stored binaries again"
+	}
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := store.New(app.Home)
+			set := s.Unpin
+			if pin {
+				set = s.Pin
+			}
+			entries, err := set(args[0])
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				fmt.Printf("%s pinned=%t\n", e.ID, e.Pinned)
+			}
+			return nil
+		},
+	}
+}
+
+// storeUsers maps store entry ids to the instances referencing them.
+func storeUsers(app *types.ManagerConfig) map[string][]string {
+	out := map[string][]string{}
+	for _, ic := range app.Instances {
+		if ic.StoreEntry != "" {
+			out[ic.StoreEntry] = append(out[ic.StoreEntry], ic.Name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	plain := []byte("priv_validator_key.json")
+	pass := []byte("passphrase")
+
+	sealed, err := Seal(plain, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) {
+		t.Fatal("sealed data lacks the header")
+	}
+	if bytes.Contains(sealed, plain) {
+		t.Fatal("sealed data contains the plaintext")
+	}
+	got, err := Open(sealed, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("opened %q, want %q", got, plain)
+	}
+
+	again, err := Seal(plain, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(again, sealed) {
+		t.Error("sealing twice gave the same output, salt or nonce is reused")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	pass := []byte("passphrase")
+	sealed, err := Seal([]byte("secret"), pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := func(i int) []byte {
+		b := append([]byte(nil), sealed...)
+		b[i] ^= 1
+		return b
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		pass []byte
+	}{
+		{name: "wrong passphrase", data: sealed, pass: []byte("Passphrase")},
+		{name: "tampered salt", data: flip(len(magic)), pass: pass},
+		{name: "tampered ciphertext", data: flip(len(sealed) - 1), pass: pass},
+		{name: "truncated", data: sealed[:len(magic)+saltSize+4], pass: pass},
+	}
+	for _, tt := range tests {
+		if _, err := Open(tt.data, tt.pass); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("%s: error = %v, want ErrDecrypt", tt.name, err)
+		}
+	}
+
+	if _, err := Open([]byte("plain text"), pass); err == nil || errors.Is(err, ErrDecrypt) {
+		t.Errorf("unsealed data: error = %v", err)
+	}
+	if _, err := Seal([]byte("secret"), nil); err == nil {
+		t.Error("sealed with an empty passphrase")
+	}
+}
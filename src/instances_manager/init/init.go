@@ -1,13 +1,354 @@
 package init
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	"github.com/PeepoFrog/sekai_manager/src/cfg"
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/downloader"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/gitres"
+	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/store"
 	"github.com/PeepoFrog/sekai_manager/src/types"
 )
 
-func InitInstance(mnemonic []byte) (*types.InstanceConfig, error) {
-	// Download or check if exists required version
-	// New or Join
-	// apply config
+const (
+	// keyring backend used for the genesis keys; keys in it are derivable from the master anyway
+	KEYRING_BACKEND string = "test"
+
+	VALIDATOR_KEY_NAME string = "validator"
+	SIGNER_KEY_NAME    string = "signer"
+
+	DEFAULT_GENESIS_COINS string = "300000000000000ukex"
+
+	// DERIVE_INSTANCE as derivation spec derives the keys by instance name.
+	DERIVE_INSTANCE string = "instance"
+)
+
+// NewOptions describes a fresh single-validator chain.
+type NewOptions struct {
+	Name          string
+	ChainID       string
+	Moniker       string
+	SekaidVersion string
+	// SekaidSource selects where sekaid comes from, see installer.ParseSource.
+	SekaidSource string
+	// IncludePrerelease lets a SekaidVersion constraint match prereleases.
+	IncludePrerelease bool
+	// InsecureSkipVerify installs sekaid even if the release has no checksum.
+	InsecureSkipVerify bool
+	// GenesisCoins are granted to both the validator and the signer account.
+	GenesisCoins string
+
+	MasterMnemonic string
+	Prefix         string
+	Path           string
+	// Derivation selects the key set taken from the master, see
+	// mnemonicderiver.ParseDerivation; DERIVE_INSTANCE derives by instance name.
+	Derivation string
+
+	HTTPClient *http.Client
+	Verbose    bool
+	// Progress receives download progress of the sekaid release asset.
+	Progress func(downloader.Progress)
+}
+
+func (o *NewOptions) setDefaults() {
+	if o.Moniker == "" {
+		o.Moniker = o.Name
+	}
+	if o.GenesisCoins == "" {
+		o.GenesisCoins = DEFAULT_GENESIS_COINS
+	}
+	if o.Prefix == "" {
+		o.Prefix = vlg.DefaultPrefix
+	}
+	if o.Path == "" {
+		o.Path = vlg.DefaultPath
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+}
+
+func (o NewOptions) validate() error {
+	switch {
+	case o.Name == "":
+		return errors.New("instance name is empty")
+	case o.ChainID == "":
+		return errors.New("chain-id is empty")
+	}
+	if valid, invalidWords := mnemonicderiver.CheckMnemonic(o.MasterMnemonic); !valid {
+		return fmt.Errorf("invalid mnemonic, invalid words: %v", invalidWords)
+	}
+	_, err := keyDerivation(o.Derivation, o.Name, o.Prefix, o.Path)
+	return err
+}
+
+// InitNew bootstraps a new chain with a single genesis validator whose keys are
+// derived from the master mnemonic, and registers the result as a managed instance.
+// If any step fails the instance is discarded again.
+func InitNew(ctx context.Context, im *instancesmanager.InstanceManager, opts NewOptions) (ic *types.InstanceConfig, err error) {
+	opts.setDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	derivation, err := keyDerivation(opts.Derivation, opts.Name, opts.Prefix, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	set, err := mnemonicderiver.GenerateMnemonicsForDerivation(opts.MasterMnemonic, derivation)
+	if err != nil {
+		return nil, err
+	}
+	validatorAddr, err := mnemonicderiver.AccAddressFromMnemonic(string(set.ValidatorAddrMnemonic), opts.Prefix, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	signerAddr, err := mnemonicderiver.AccAddressFromMnemonic(string(set.SignerAddrMnemonic), opts.Prefix, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := prepareInstance(ctx, im, opts.Name, installSpec{
+		source:             opts.SekaidSource,
+		version:            opts.SekaidVersion,
+		includePrerelease:  opts.IncludePrerelease,
+		insecureSkipVerify: opts.InsecureSkipVerify,
+		verbose:            opts.Verbose,
+		progress:           opts.Progress,
+	}, opts.HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = im.DiscardInstance(inst.Name)
+		}
+	}()
+	inst.ChainID = opts.ChainID
+	inst.Moniker = opts.Moniker
+	inst.Derivation = types.KeyDerivation(derivation)
+
+	home := []string{"--home", inst.Home}
+	keyring := append([]string{"--keyring-backend", KEYRING_BACKEND}, home...)
+
+	if _, err = runSekaid(ctx, inst.Binary, nil, append([]string{"init", opts.Moniker, "--chain-id", opts.ChainID, "--overwrite"}, home...)...); err != nil {
+		return nil, err
+	}
+	// sekaid init generates random keys, replace them with the derived ones
+	if err = mnemonicderiver.SetSekaidPrivKeys(set, inst.Home); err != nil {
+		return nil, err
+	}
+
+	keys := []genesisKey{
+		{VALIDATOR_KEY_NAME, set.ValidatorAddrMnemonic, validatorAddr},
+		{SIGNER_KEY_NAME, set.SignerAddrMnemonic, signerAddr},
+	}
+	for _, k := range keys {
+		if err = addGenesisKey(ctx, inst.Binary, keyring, k, opts.Prefix, opts.Path, opts.GenesisCoins); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err = runSekaid(ctx, inst.Binary, nil, append([]string{"gentx-claim", VALIDATOR_KEY_NAME, "--moniker", opts.Moniker}, keyring...)...); err != nil {
+		return nil, err
+	}
+
+	if err = applyInstanceConfig(im, inst); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// genesisKey is a derived account that is funded in genesis.
+type genesisKey struct {
+	name     string
+	mnemonic []byte
+	addr     string
+}
+
+// addGenesisKey recovers k into the keyring along the same hd path it was derived
+// with and funds it in genesis. sekaid derives the address on its own, so it is read
+// back and has to be the one derived from the master.
+func addGenesisKey(ctx context.Context, bin string, keyring []string, k genesisKey, prefix, path, coins string) error {
+	stdin := bytes.NewReader(append(append([]byte(nil), k.mnemonic...), '\n'))
+	if _, err := runSekaid(ctx, bin, stdin, append([]string{"keys", "add", k.name, "--recover", "--hd-path", path}, keyring...)...); err != nil {
+		return err
+	}
+	out, err := runSekaid(ctx, bin, nil, append([]string{"keys", "show", k.name, "-a"}, keyring...)...)
+	if err != nil {
+		return err
+	}
+	lines := strings.Fields(string(out))
+	if len(lines) == 0 || lines[len(lines)-1] != k.addr {
+		return fmt.Errorf("sekaid recovered key %s as %q, but %s was derived with prefix %q and path %q", k.name, strings.TrimSpace(string(out)), k.addr, prefix, path)
+	}
+	_, err = runSekaid(ctx, bin, nil, append([]string{"add-genesis-account", k.addr, coins}, keyring...)...)
+	return err
+}
+
+// keyDerivation resolves a derivation spec for the named instance and records
+// prefix and path with it, so the keys can be derived again later.
+func keyDerivation(spec, name, prefix, path string) (mnemonicderiver.Derivation, error) {
+	if spec == DERIVE_INSTANCE {
+		spec = mnemonicderiver.DERIVATION_NAME + ":" + name
+	}
+	d, err := mnemonicderiver.ParseDerivation(spec)
+	if err != nil {
+		return d, err
+	}
+	d.Prefix, d.Path = prefix, path
+	return d, nil
+}
+
+// installSpec selects and verifies the sekaid binary of a new instance.
+type installSpec struct {
+	// source is an installer source, see installer.ParseSource; empty means GitHub releases.
+	source string
+	// version may be a constraint, it is resolved to a release tag first.
+	version            string
+	includePrerelease  bool
+	insecureSkipVerify bool
+	verbose            bool
+	progress           func(downloader.Progress)
+}
+
+// prepareInstance registers a new instance and installs its sekaid binary.
+func prepareInstance(ctx context.Context, im *instancesmanager.InstanceManager, name string, spec installSpec, client *http.Client) (*types.InstanceConfig, error) {
+	if err := im.CreateInstance(name); err != nil {
+		return nil, err
+	}
+	ic, err := im.GetInstance(name)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := gitHubClient(im.ManagerConfig, client)
+	if err != nil {
+		_ = im.DiscardInstance(name)
+		return nil, err
+	}
+	api.Verbose = spec.verbose
+	bins := store.New(im.Home)
+	inst, err := installer.FromSource(spec.source, client, installer.InstallOptions{
+		GitHub:             api,
+		TrustedKeys:        im.TrustedKeys,
+		AssetRules:         gitres.AssetRules(im.AssetRules),
+		InsecureSkipVerify: spec.insecureSkipVerify,
+		IncludePrerelease:  spec.includePrerelease,
+		Verbose:            spec.verbose,
+		Progress:           spec.progress,
+		DownloadDir:        bins.DownloadDir(),
+	})
+	if err != nil {
+		_ = im.DiscardInstance(name)
+		return nil, err
+	}
+	tag, err := inst.Resolve(ctx, spec.version)
+	if err != nil {
+		_ = im.DiscardInstance(name)
+		return nil, err
+	}
+	// the binary must report the requested version before it enters the store
+	var build *installer.BuildInfo
+	fetch := func(stageDir string) (string, error) {
+		res, err := inst.Install(ctx, tag, stageDir)
+		if err != nil {
+			return "", err
+		}
+		if build, err = installer.VerifyBinary(ctx, res.BinaryPath, tag); err != nil {
+			return "", err
+		}
+		return res.AssetDigest, nil
+	}
+	var entry *store.Entry
+	if inst.Reusable() {
+		entry, err = bins.Ensure(tag, inst.Source(), fetch)
+	} else {
+		entry, err = bins.Add(tag, inst.Source(), fetch)
+	}
+	if err == nil && build == nil {
+		// reused from the store, check it still is what it claims to be
+		build, err = installer.VerifyBinary(ctx, entry.Binary, tag)
+	}
+	if err != nil {
+		_ = im.DiscardInstance(name)
+		return nil, err
+	}
+	ic.SekaidVersion = spec.version
+	ic.ResolvedVersion = tag
+	ic.SekaidSource = inst.Source()
+	ic.Binary = entry.Binary
+	ic.StoreEntry = entry.ID
+	// empty for an asset installed with verification skipped
+	ic.SekaidDigest = entry.AssetDigest
+	ic.Build = types.BuildInfo(*build)
+	// record the store entry now, not only after the slow init steps, so a
+	// concurrent store prune sees it as referenced
+	if err := im.UpdateInstance(ic); err != nil {
+		_ = im.DiscardInstance(name)
+		return nil, err
+	}
+	return &ic, nil
+}
+
+// gitHubClient builds the GitHub API client described by the [github] table.
+// Release metadata is cached under <home>/cache/github unless disabled.
+func gitHubClient(mc *types.ManagerConfig, httpClient *http.Client) (*gitres.Client, error) {
+	if err := cfg.ValidateGitHubConfig(mc.GitHub); err != nil {
+		return nil, err
+	}
+	c := gitres.NewClient(httpClient)
+	if mc.GitHub.APIBaseURL != "" {
+		c.BaseURL = mc.GitHub.APIBaseURL
+	}
+	if c.Token == "" {
+		c.Token = strings.TrimSpace(mc.GitHub.Token)
+	}
+	if mc.GitHub.RateLimitWait != "" {
+		c.MaxRateLimitWait, _ = time.ParseDuration(mc.GitHub.RateLimitWait)
+	}
+	if !mc.GitHub.NoCache && mc.Home != "" {
+		c.CacheDir = filepath.Join(mc.Home, cfg.CACHE_FOLDER_NAME, "github")
+	}
+	return c, nil
+}
+
+// applyInstanceConfig writes the instance's address binding into its sekaid config
+// files and persists the instance record.
+func applyInstanceConfig(im *instancesmanager.InstanceManager, ic *types.InstanceConfig) error {
+	ab, err := cfg.BindingOf(*ic)
+	if err != nil {
+		return err
+	}
+	if err := cfg.WriteAddressBinding(ic.Home, ab); err != nil {
+		return err
+	}
+	return im.UpdateInstance(*ic)
+}
 
-	return &types.InstanceConfig{}, nil
+// runSekaid runs the binary and returns its combined output.
+// The output is part of the returned error when the command fails.
+func runSekaid(ctx context.Context, bin string, stdin *bytes.Reader, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, bin, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("sekaid %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
 }
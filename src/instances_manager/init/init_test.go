@@ -0,0 +1,181 @@
+package init
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	"github.com/PeepoFrog/sekai_manager/src/cfg"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/gitres"
+	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+func TestGitHubClient(t *testing.T) {
+	home := t.TempDir()
+	tests := []struct {
+		name      string
+		env       map[string]string
+		gc        types.GitHubConfig
+		wantToken string
+		wantBase  string
+		wantWait  time.Duration
+		wantErr   bool
+	}{
+		{name: "defaults", wantBase: gitres.DefaultAPIBaseURL},
+		{name: "config token", gc: types.GitHubConfig{Token: " cfg-token "}, wantToken: "cfg-token"},
+		{name: "env token wins", env: map[string]string{"GITHUB_TOKEN": "env-token"}, gc: types.GitHubConfig{Token: "cfg-token"}, wantToken: "env-token"},
+		{name: "env order", env: map[string]string{"GH_TOKEN": "gh", "SEKAI_MANAGER_GITHUB_TOKEN": "manager"}, wantToken: "manager"},
+		{name: "enterprise", gc: types.GitHubConfig{APIBaseURL: "https://github.example.com/api/v3"}, wantBase: "https://github.example.com/api/v3"},
+		{name: "rate limit wait", gc: types.GitHubConfig{RateLimitWait: "10m"}, wantWait: 10 * time.Minute},
+		{name: "no cache", gc: types.GitHubConfig{NoCache: true}},
+		{name: "invalid base", gc: types.GitHubConfig{APIBaseURL: "github.example.com"}, wantErr: true},
+		{name: "invalid wait", gc: types.GitHubConfig{RateLimitWait: "soon"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range gitres.TokenEnvVars {
+				t.Setenv(name, tt.env[name])
+			}
+			c, err := gitHubClient(&types.ManagerConfig{Home: home, GitHub: tt.gc}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if c.Token != tt.wantToken {
+				t.Errorf("token %q, want %q", c.Token, tt.wantToken)
+			}
+			if tt.wantBase != "" && c.BaseURL != tt.wantBase {
+				t.Errorf("base %q, want %q", c.BaseURL, tt.wantBase)
+			}
+			if c.MaxRateLimitWait != tt.wantWait {
+				t.Errorf("rate limit wait %s, want %s", c.MaxRateLimitWait, tt.wantWait)
+			}
+			wantCache := filepath.Join(home, cfg.CACHE_FOLDER_NAME, "github")
+			if tt.gc.NoCache {
+				wantCache = ""
+			}
+			if c.CacheDir != wantCache {
+				t.Errorf("cache %q, want %q", c.CacheDir, wantCache)
+			}
+		})
+	}
+}
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// fakeSekaid writes a sekaid stand-in that logs its arguments to <dir>/args and the
+// mnemonic of `keys add` to <dir>/stdin, and prints showAddr for `keys show`.
+func fakeSekaid(t *testing.T, showAddr string) (bin, dir string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries are shell scripts")
+	}
+	dir = t.TempDir()
+	bin = filepath.Join(dir, "sekaid")
+	script := "#!/bin/sh\n" +
+		`echo "$*" >> "` + filepath.Join(dir, "args") + `"` + "\n" +
+		`case "$1 $2" in` + "\n" +
+		`"keys add") cat > "` + filepath.Join(dir, "stdin") + `" ;;` + "\n" +
+		`"keys show") echo "` + showAddr + `" ;;` + "\n" +
+		"esac\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return bin, dir
+}
+
+func TestAddGenesisKey(t *testing.T) {
+	const path = "m/44'/118'/0'/0/3"
+	addr, err := mnemonicderiver.AccAddressFromMnemonic(testMnemonic, vlg.DefaultPrefix, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, dir := fakeSekaid(t, addr)
+	keyring := []string{"--keyring-backend", KEYRING_BACKEND, "--home", "/h"}
+
+	k := genesisKey{name: VALIDATOR_KEY_NAME, mnemonic: []byte(testMnemonic), addr: addr}
+	if err := addGenesisKey(context.Background(), bin, keyring, k, vlg.DefaultPrefix, path, "100ukex"); err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "keys add validator --recover --hd-path " + path + " --keyring-backend test --home /h\n" +
+		"keys show validator -a --keyring-backend test --home /h\n" +
+		"add-genesis-account " + addr + " 100ukex --keyring-backend test --home /h\n"
+	if string(args) != want {
+		t.Errorf("sekaid ran with\n%s\nwant\n%s", args, want)
+	}
+	stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stdin) != testMnemonic+"\n" {
+		t.Errorf("keys add read %q", stdin)
+	}
+}
+
+func TestAddGenesisKeyAddressMismatch(t *testing.T) {
+	// sekaid only knows its own prefix, a key derived with another one is not its account
+	addr, err := mnemonicderiver.AccAddressFromMnemonic(testMnemonic, vlg.DefaultPrefix, vlg.DefaultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := mnemonicderiver.AccAddressFromMnemonic(testMnemonic, "cosmos", vlg.DefaultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, dir := fakeSekaid(t, addr)
+
+	k := genesisKey{name: SIGNER_KEY_NAME, mnemonic: []byte(testMnemonic), addr: other}
+	err = addGenesisKey(context.Background(), bin, nil, k, "cosmos", vlg.DefaultPath, "100ukex")
+	if err == nil || !strings.Contains(err.Error(), `prefix "cosmos"`) {
+		t.Fatalf("want address mismatch error, got %v", err)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if strings.Contains(string(args), "add-genesis-account") {
+		t.Errorf("an account sekaid holds no key of was funded:\n%s", args)
+	}
+}
+
+func TestKeyDerivation(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    mnemonicderiver.Derivation
+		wantErr bool
+	}{
+		{spec: "master", want: mnemonicderiver.Derivation{Scheme: mnemonicderiver.DERIVATION_MASTER}},
+		{spec: "index:2", want: mnemonicderiver.Derivation{Scheme: mnemonicderiver.DERIVATION_INDEX, Index: 2}},
+		{spec: "name:other", want: mnemonicderiver.Derivation{Scheme: mnemonicderiver.DERIVATION_NAME, Name: "other"}},
+		{spec: DERIVE_INSTANCE, want: mnemonicderiver.Derivation{Scheme: mnemonicderiver.DERIVATION_NAME, Name: "node-1"}},
+		{spec: "index:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := keyDerivation(tt.spec, "node-1", vlg.DefaultPrefix, vlg.DefaultPath)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		tt.want.Prefix, tt.want.Path = vlg.DefaultPrefix, vlg.DefaultPath
+		if got != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.spec, got, tt.want)
+		}
+		// what is recorded in the instance config converts back unchanged
+		if back := mnemonicderiver.Derivation(types.KeyDerivation(got)); back != got {
+			t.Errorf("%q: recorded as %+v", tt.spec, back)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package init
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	"github.com/PeepoFrog/sekai_manager/src/cfg"
+	instancesmanager "github.com/PeepoFrog/sekai_manager/src/instances_manager"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/downloader"
+	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
+	tmrpc "github.com/PeepoFrog/sekai_manager/src/instances_manager/tm_rpc"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+// ErrGenesisUnverifiable is returned when a join has no way to verify genesis.json
+// and InsecureSkipGenesisVerify is not set.
+var ErrGenesisUnverifiable = errors.New("genesis.json can not be verified without a genesis checksum or interx")
+
+// JoinOptions describes joining an existing network through a trusted node.
+type JoinOptions struct {
+	Name          string
+	Moniker       string
+	SekaidVersion string
+	// SekaidSource selects where sekaid comes from, see installer.ParseSource.
+	SekaidSource string
+	// IncludePrerelease lets a SekaidVersion constraint match prereleases.
+	IncludePrerelease bool
+	// InsecureSkipVerify installs sekaid even if the release has no checksum.
+	InsecureSkipVerify bool
+
+	// TrustedRPC is the Tendermint RPC of the node we trust, e.g. http://1.2.3.4:26657.
+	TrustedRPC string
+	// Interx optionally points to the trusted node's interx; its /api/gensum is used
+	// to verify genesis.json when GenesisChecksum is not given.
+	Interx string
+	// GenesisChecksum is the expected sha256 of genesis.json (hex, optional 0x prefix).
+	GenesisChecksum string
+	// InsecureSkipGenesisVerify joins even though neither GenesisChecksum nor Interx
+	// is given, i.e. genesis.json is taken from the trusted node unchecked.
+	InsecureSkipGenesisVerify bool
+	// Seed configures the trusted node as seed instead of persistent peer.
+	Seed bool
+	// ExtraPeers are appended to persistent_peers as they are (id@host:port).
+	ExtraPeers []string
+
+	// MasterMnemonic is optional; when set the node keys are derived from it.
+	MasterMnemonic string
+	Prefix         string
+	Path           string
+	// Derivation selects the key set taken from the master, see NewOptions.
+	Derivation string
+
+	HTTPClient *http.Client
+	Verbose    bool
+	// Progress receives download progress of the sekaid release asset.
+	Progress func(downloader.Progress)
+}
+
+func (o *JoinOptions) setDefaults() {
+	if o.Moniker == "" {
+		o.Moniker = o.Name
+	}
+	if o.Prefix == "" {
+		o.Prefix = vlg.DefaultPrefix
+	}
+	if o.Path == "" {
+		o.Path = vlg.DefaultPath
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+}
+
+func (o JoinOptions) validate() error {
+	switch {
+	case o.Name == "":
+		return errors.New("instance name is empty")
+	case o.TrustedRPC == "":
+		return errors.New("trusted rpc is empty")
+	case o.GenesisChecksum == "" && o.Interx == "" && !o.InsecureSkipGenesisVerify:
+		return ErrGenesisUnverifiable
+	}
+	if o.MasterMnemonic != "" {
+		if valid, invalidWords := mnemonicderiver.CheckMnemonic(o.MasterMnemonic); !valid {
+			return fmt.Errorf("invalid mnemonic, invalid words: %v", invalidWords)
+		}
+		if _, err := keyDerivation(o.Derivation, o.Name, o.Prefix, o.Path); err != nil {
+			return err
+		}
+	} else if o.Derivation != "" {
+		return errors.New("a derivation needs a master mnemonic")
+	}
+	return nil
+}
+
+// InitJoin creates an instance that follows an existing network. genesis.json is
+// fetched from the trusted node and verified, and the node is configured as peer or seed.
+// If any step fails the instance is discarded again.
+func InitJoin(ctx context.Context, im *instancesmanager.InstanceManager, opts JoinOptions) (ic *types.InstanceConfig, err error) {
+	opts.setDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	trusted, err := queryTrustedNode(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := prepareInstance(ctx, im, opts.Name, installSpec{
+		source:             opts.SekaidSource,
+		version:            opts.SekaidVersion,
+		includePrerelease:  opts.IncludePrerelease,
+		insecureSkipVerify: opts.InsecureSkipVerify,
+		verbose:            opts.Verbose,
+		progress:           opts.Progress,
+	}, opts.HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = im.DiscardInstance(inst.Name)
+		}
+	}()
+	inst.ChainID = trusted.network
+	inst.Moniker = opts.Moniker
+
+	if _, err = runSekaid(ctx, inst.Binary, nil, "init", opts.Moniker, "--chain-id", inst.ChainID, "--overwrite", "--home", inst.Home); err != nil {
+		return nil, err
+	}
+	if opts.MasterMnemonic != "" {
+		var (
+			derivation mnemonicderiver.Derivation
+			set        *vlg.MasterMnemonicSet
+		)
+		if derivation, err = keyDerivation(opts.Derivation, opts.Name, opts.Prefix, opts.Path); err != nil {
+			return nil, err
+		}
+		if set, err = mnemonicderiver.GenerateMnemonicsForDerivation(opts.MasterMnemonic, derivation); err != nil {
+			return nil, err
+		}
+		if err = mnemonicderiver.SetSekaidPrivKeys(set, inst.Home); err != nil {
+			return nil, err
+		}
+		inst.Derivation = types.KeyDerivation(derivation)
+	}
+
+	if err = os.WriteFile(filepath.Join(inst.Home, "config", "genesis.json"), trusted.genesis, 0o644); err != nil {
+		return nil, err
+	}
+
+	peers := append([]string{}, opts.ExtraPeers...)
+	var seeds []string
+	if opts.Seed {
+		seeds = append(seeds, trusted.peer)
+	} else {
+		peers = append([]string{trusted.peer}, peers...)
+	}
+	if err = cfg.PatchTomlFile(filepath.Join(inst.Home, "config", cfg.SEKAID_CONFIG_TOML), map[cfg.TomlKey]string{
+		{Table: "p2p", Key: "persistent_peers"}: strings.Join(peers, ","),
+		{Table: "p2p", Key: "seeds"}:            strings.Join(seeds, ","),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err = applyInstanceConfig(im, inst); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// trustedNode is what a join takes over from the trusted node.
+type trustedNode struct {
+	network string
+	peer    string
+	genesis []byte
+}
+
+// queryTrustedNode fetches the status and genesis.json of the trusted node and
+// verifies the genesis before anything is created locally.
+func queryTrustedNode(ctx context.Context, opts JoinOptions) (*trustedNode, error) {
+	client, err := tmrpc.NewClient(opts.TrustedRPC, opts.HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+	status, err := client.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query trusted node status: %w", err)
+	}
+	peer, err := peerAddress(client.BaseURL, status.NodeInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis, err := client.Genesis(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch genesis: %w", err)
+	}
+	if err := verifyGenesis(ctx, opts, genesis, status.NodeInfo.Network); err != nil {
+		return nil, err
+	}
+	return &trustedNode{network: status.NodeInfo.Network, peer: peer, genesis: genesis}, nil
+}
+
+// peerAddress builds id@host:port from the host we reached the node at
+// and the p2p port it listens on.
+func peerAddress(rpcURL string, ni tmrpc.NodeInfo) (string, error) {
+	if ni.ID == "" {
+		return "", errors.New("trusted node did not report its node id")
+	}
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return "", err
+	}
+	p2pURL, err := url.Parse(ni.ListenAddr)
+	if err != nil || p2pURL.Port() == "" {
+		return "", fmt.Errorf("unable to parse p2p listen address %q", ni.ListenAddr)
+	}
+	return fmt.Sprintf("%s@%s", ni.ID, net.JoinHostPort(u.Hostname(), p2pURL.Port())), nil
+}
+
+// verifyGenesis checks the chain id and compares the sha256 of genesis.json with the
+// checksum given in the options or, if none is given, the one published by interx.
+// Without either the genesis is only accepted under InsecureSkipGenesisVerify.
+func verifyGenesis(ctx context.Context, opts JoinOptions, genesis []byte, network string) error {
+	var doc struct {
+		ChainID string `json:"chain_id"`
+	}
+	if err := json.Unmarshal(genesis, &doc); err != nil {
+		return fmt.Errorf("genesis is not valid json: %w", err)
+	}
+	if doc.ChainID != network {
+		return fmt.Errorf("genesis chain_id %q does not match network %q of the trusted node", doc.ChainID, network)
+	}
+
+	sum := sha256.Sum256(genesis)
+	got := hex.EncodeToString(sum[:])
+
+	want := opts.GenesisChecksum
+	if want == "" && opts.Interx != "" {
+		var err error
+		if want, err = interxGenesisChecksum(ctx, opts.HTTPClient, opts.Interx); err != nil {
+			return err
+		}
+	}
+	if want == "" {
+		if opts.InsecureSkipGenesisVerify {
+			return nil
+		}
+		return ErrGenesisUnverifiable
+	}
+	want = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(want), "0x"))
+	if want != got {
+		return fmt.Errorf("genesis checksum mismatch: want %s, got %s", want, got)
+	}
+	return nil
+}
+
+func interxGenesisChecksum(ctx context.Context, client *http.Client, interx string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(interx, "/")+"/api/gensum", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("interx gensum returned HTTP %d", resp.StatusCode)
+	}
+	var r struct {
+		Checksum string `json:"checksum"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", err
+	}
+	if r.Checksum == "" {
+		return "", errors.New("interx gensum returned an empty checksum")
+	}
+	return r.Checksum, nil
+}
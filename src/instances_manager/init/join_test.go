@@ -0,0 +1,133 @@
+package init
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+const testGenesis = `{"chain_id":"testnet-1","initial_height":"1"}`
+
+func genesisSum(genesis string) string {
+	sum := sha256.Sum256([]byte(genesis))
+	return hex.EncodeToString(sum[:])
+}
+
+// trustedNodeStub stands in for the Tendermint RPC of the trusted node.
+func trustedNodeStub(t *testing.T, network, genesis string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/status":
+			fmt.Fprintf(w, `{"result":{"node_info":{"id":"abc123","listen_addr":"tcp://0.0.0.0:26656","network":%q}}}`, network)
+		case "/genesis_chunked":
+			fmt.Fprintf(w, `{"result":{"chunk":"0","total":"1","data":%q}}`, base64.StdEncoding.EncodeToString([]byte(genesis)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// interxStub publishes checksum on /api/gensum, or fails with status if it is not 200.
+func interxStub(t *testing.T, status int, checksum string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/gensum" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"checksum":%q}`, checksum)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestQueryTrustedNode(t *testing.T) {
+	rpc, _ := trustedNodeStub(t, "testnet-1", testGenesis)
+	sum := genesisSum(testGenesis)
+	other := genesisSum(`{"chain_id":"testnet-1"}`)
+
+	tests := []struct {
+		name    string
+		opts    JoinOptions
+		network string
+		wantErr bool
+	}{
+		{name: "checksum", opts: JoinOptions{GenesisChecksum: sum}},
+		{name: "checksum with prefix", opts: JoinOptions{GenesisChecksum: "0x" + sum}},
+		{name: "checksum mismatch", opts: JoinOptions{GenesisChecksum: other}, wantErr: true},
+		{name: "interx", opts: JoinOptions{Interx: interxStub(t, http.StatusOK, "0x"+sum).URL}},
+		{name: "interx mismatch", opts: JoinOptions{Interx: interxStub(t, http.StatusOK, other).URL}, wantErr: true},
+		{name: "interx failure", opts: JoinOptions{Interx: interxStub(t, http.StatusInternalServerError, sum).URL}, wantErr: true},
+		{name: "interx empty checksum", opts: JoinOptions{Interx: interxStub(t, http.StatusOK, "").URL}, wantErr: true},
+		{name: "checksum wins over interx", opts: JoinOptions{GenesisChecksum: sum, Interx: interxStub(t, http.StatusOK, other).URL}},
+		{name: "insecure", opts: JoinOptions{InsecureSkipGenesisVerify: true}},
+		{name: "chain id mismatch", opts: JoinOptions{GenesisChecksum: sum}, network: "testnet-2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Name = "node"
+			opts.TrustedRPC = rpc.URL
+			if tt.network != "" {
+				other, _ := trustedNodeStub(t, tt.network, testGenesis)
+				opts.TrustedRPC = other.URL
+			}
+			opts.setDefaults()
+			if err := opts.validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			trusted, err := queryTrustedNode(context.Background(), opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(trusted.genesis) != testGenesis {
+				t.Fatalf("genesis %s", trusted.genesis)
+			}
+			u, _ := url.Parse(rpc.URL)
+			if want := "abc123@" + u.Hostname() + ":26656"; trusted.peer != want {
+				t.Fatalf("peer %s, want %s", trusted.peer, want)
+			}
+			if trusted.network != "testnet-1" {
+				t.Fatalf("network %s", trusted.network)
+			}
+		})
+	}
+}
+
+func TestInitJoinRequiresGenesisVerification(t *testing.T) {
+	rpc, hits := trustedNodeStub(t, "testnet-1", testGenesis)
+	_, err := InitJoin(context.Background(), nil, JoinOptions{Name: "node", TrustedRPC: rpc.URL})
+	if !errors.Is(err, ErrGenesisUnverifiable) {
+		t.Fatalf("expected ErrGenesisUnverifiable, got %v", err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Fatalf("trusted node was queried %d times", n)
+	}
+
+	// verifyGenesis refuses on its own as well
+	opts := JoinOptions{}
+	if err := verifyGenesis(context.Background(), opts, []byte(testGenesis), "testnet-1"); !errors.Is(err, ErrGenesisUnverifiable) {
+		t.Fatalf("expected ErrGenesisUnverifiable, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/gitres"
+)
+
+const (
+	// DEFAULT_BUILD_PACKAGE is the sekaid main package inside a sekai checkout.
+	DEFAULT_BUILD_PACKAGE string = "./cmd/sekaid"
+
+	// sdkVersionPkg holds the variables `sekaid version` reports, set like sekai's Makefile does.
+	sdkVersionPkg = "github.com/cosmos/cosmos-sdk/version"
+)
+
+// Build compiles sekaid from a local source checkout with the go toolchain.
+type Build struct {
+	Dir string
+	// Package is built relative to Dir, DEFAULT_BUILD_PACKAGE if empty.
+	Package string
+	Options InstallOptions
+}
+
+func (b *Build) Source() string { return SOURCE_BUILD + ":" + b.Dir }
+
+// Reusable is false, the checkout may change between installs.
+func (b *Build) Reusable() bool { return false }
+
+// Resolve takes an exact version as given; without one the version is read from
+// `git describe --tags` of the checkout.
+func (b *Build) Resolve(ctx context.Context, version string) (string, error) {
+	if strings.TrimSpace(version) != "" {
+		return exactVersion(b.Source(), version)
+	}
+	described, err := b.gitOutput(ctx, "describe", "--tags", "--dirty")
+	if err != nil {
+		return "", fmt.Errorf("unable to read version of %s from git, pass an exact sekaid version: %w", b.Dir, err)
+	}
+	if _, err := gitres.ParseVersion(described); err != nil {
+		return "", fmt.Errorf("git describe of %s gave %q: %w", b.Dir, described, err)
+	}
+	return described, nil
+}
+
+// Install runs `go build` and leaves the binary in binDir. There is no release
+// asset, so the result carries no asset digest.
+// The version a build reports is injected from the requested one, so checking the
+// binary afterwards proves nothing: the checkout itself must be at that version
+// according to `git describe`, and the binary must report the checked out commit.
+func (b *Build) Install(ctx context.Context, version, binDir string) (*InstallResult, error) {
+	commit, err := b.checkoutVersion(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := b.Package
+	if pkg == "" {
+		pkg = DEFAULT_BUILD_PACKAGE
+	}
+	absBinDir, err := filepath.Abs(binDir)
+	if err != nil {
+		return nil, err
+	}
+	out := filepath.Join(absBinDir, SEKAID_BIN_NAME)
+
+	ldflags := []string{
+		"-X " + sdkVersionPkg + ".Name=sekai",
+		"-X " + sdkVersionPkg + ".AppName=" + SEKAID_BIN_NAME,
+		"-X " + sdkVersionPkg + ".Version=" + version,
+		"-X " + sdkVersionPkg + ".Commit=" + commit,
+	}
+
+	return inBinDir(binDir, func() (*InstallResult, error) {
+		cmd := exec.CommandContext(ctx, "go", "build", "-trimpath", "-ldflags", strings.Join(ldflags, " "), "-o", out, pkg)
+		cmd.Dir = b.Dir
+		var output bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &output, &output
+		if b.Options.Verbose {
+			fmt.Printf("Building %s in %s\n", pkg, b.Dir)
+			cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		}
+		if err := cmd.Run(); err != nil {
+			_ = os.Remove(out)
+			return nil, fmt.Errorf("go build %s in %s: %w\n%s", pkg, b.Dir, err, strings.TrimSpace(output.String()))
+		}
+		info, err := ReadBuildInfo(ctx, out)
+		if err == nil && info.Commit != commit {
+			err = fmt.Errorf("%w: built from commit %s, binary reports %q", ErrVersionMismatch, commit, info.Commit)
+		}
+		if err != nil {
+			_ = os.Remove(out)
+			return nil, err
+		}
+		return &InstallResult{BinaryPath: out}, nil
+	})
+}
+
+// checkoutVersion makes sure `git describe --tags --dirty` of the checkout is version
+// and returns the checked out commit.
+func (b *Build) checkoutVersion(ctx context.Context, version string) (string, error) {
+	commit, err := b.gitOutput(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("unable to read the commit of %s: %w", b.Dir, err)
+	}
+	described, err := b.gitOutput(ctx, "describe", "--tags", "--dirty")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s has no tag to check %s against: %v", ErrVersionMismatch, b.Dir, version, err)
+	}
+	want, err := gitres.ParseVersion(version)
+	if err != nil {
+		return "", err
+	}
+	if got, err := gitres.ParseVersion(described); err != nil || got.Compare(want) != 0 {
+		return "", fmt.Errorf("%w: requested %s, checkout %s is at %s (commit %s)", ErrVersionMismatch, version, b.Dir, described, commit)
+	}
+	return commit, nil
+}
+
+func (b *Build) gitOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = b.Dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
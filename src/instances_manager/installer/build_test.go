@@ -0,0 +1,107 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeCheckout creates a git checkout tagged tag whose ./cmd/sekaid reports the
+// cosmos-sdk version variables Build injects.
+func fakeCheckout(t *testing.T, tag string) (dir, commit string) {
+	t.Helper()
+	for _, tool := range []string{"git", "go"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found", tool)
+		}
+	}
+	dir = t.TempDir()
+	files := map[string]string{
+		"go.mod":             "module github.com/cosmos/cosmos-sdk\n\ngo 1.21\n",
+		"version/version.go": "package version\n\nvar (\n\tName    string\n\tAppName string\n\tVersion string\n\tCommit  string\n)\n",
+		"cmd/sekaid/main.go": `package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cosmos/cosmos-sdk/version"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "version" {
+		os.Exit(2)
+	}
+	fmt.Printf("name: %s\nserver_name: %s\nversion: %s\ncommit: %s\n", version.Name, version.AppName, version.Version, version.Commit)
+}
+`,
+	}
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-qm", "sekai")
+	git("tag", tag)
+	return dir, git("rev-parse", "HEAD")
+}
+
+func TestBuildChecksCheckoutVersion(t *testing.T) {
+	dir, commit := fakeCheckout(t, "v0.4.1")
+	b := &Build{Dir: dir}
+	ctx := context.Background()
+
+	if v, err := b.Resolve(ctx, ""); err != nil || v != "v0.4.1" {
+		t.Fatalf("resolve: %q, %v", v, err)
+	}
+
+	// the requested version would be injected into the binary, it must be the checkout's
+	binDir := filepath.Join(t.TempDir(), "bin")
+	if _, err := b.Install(ctx, "v0.4.2", binDir); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch, got %v", err)
+	}
+	if _, err := os.Stat(binDir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("a mismatching version was built")
+	}
+
+	res, err := b.Install(ctx, "v0.4.1", binDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := VerifyBinary(ctx, res.BinaryPath, "v0.4.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Commit != commit {
+		t.Fatalf("binary reports commit %q, want %q", info.Commit, commit)
+	}
+
+	// local changes are not the tagged version
+	if err := os.WriteFile(filepath.Join(dir, "version", "version.go"), []byte("package version\n\nvar Name, AppName, Version, Commit string\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Install(ctx, "v0.4.1", filepath.Join(t.TempDir(), "bin")); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("dirty checkout: expected ErrVersionMismatch, got %v", err)
+	}
+}
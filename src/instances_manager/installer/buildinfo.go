@@ -0,0 +1,115 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/gitres"
+)
+
+// VersionCheckTimeout bounds `sekaid version --long`.
+const VersionCheckTimeout = 30 * time.Second
+
+var ErrVersionMismatch = errors.New("sekaid version mismatch")
+
+// BuildInfo is what `sekaid version --long` reports about a binary.
+type BuildInfo struct {
+	Version   string
+	Commit    string
+	GoVersion string
+}
+
+// ReadBuildInfo runs `<binary> version --long` and parses its output.
+func ReadBuildInfo(ctx context.Context, binary string) (*BuildInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, VersionCheckTimeout)
+	defer cancel()
+	// older cosmos-sdk versions print to stderr
+	out, err := exec.CommandContext(ctx, binary, "version", "--long").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s version --long: %w\n%s", binary, err, strings.TrimSpace(string(out)))
+	}
+	info, err := parseBuildInfo(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s version --long: %w", binary, err)
+	}
+	return info, nil
+}
+
+// VerifyBinary checks that binary runs and reports version; a v prefix and build
+// metadata do not matter, anything else is ErrVersionMismatch.
+func VerifyBinary(ctx context.Context, binary, version string) (*BuildInfo, error) {
+	info, err := ReadBuildInfo(ctx, binary)
+	if err != nil {
+		return nil, err
+	}
+	want, err := gitres.ParseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	got, err := gitres.ParseVersion(info.Version)
+	if err != nil || got.Compare(want) != 0 {
+		return info, fmt.Errorf("%w: requested %s, binary reports %q (commit %s, %s)",
+			ErrVersionMismatch, version, info.Version, orUnknown(info.Commit), orUnknown(info.GoVersion))
+	}
+	return info, nil
+}
+
+// parseBuildInfo reads the "key: value" (or --output json) form of the cosmos-sdk
+// version command. Nested lists like build_deps are skipped.
+func parseBuildInfo(out []byte) (*BuildInfo, error) {
+	var fields map[string]string
+	if trimmed := bytes.TrimSpace(out); bytes.HasPrefix(trimmed, []byte("{")) {
+		var raw map[string]any
+		if err := json.Unmarshal(trimmed, &raw); err != nil {
+			return nil, fmt.Errorf("parse version output: %w", err)
+		}
+		fields = make(map[string]string, len(raw))
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				fields[k] = s
+			}
+		}
+	} else {
+		fields = map[string]string{}
+		sc := bufio.NewScanner(bytes.NewReader(out))
+		for sc.Scan() {
+			line := sc.Text()
+			if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '-' {
+				continue
+			}
+			if k, v, ok := strings.Cut(line, ":"); ok {
+				fields[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"'`)
+			}
+		}
+	}
+
+	info := &BuildInfo{Version: fields["version"], Commit: fields["commit"], GoVersion: goVersion(fields["go"])}
+	if info.Version == "" {
+		return nil, fmt.Errorf("no version in output: %q", strings.TrimSpace(string(out)))
+	}
+	return info, nil
+}
+
+// goVersion extracts "go1.21.6" from "go version go1.21.6 linux/amd64".
+func goVersion(s string) string {
+	for _, f := range strings.Fields(s) {
+		if strings.HasPrefix(f, "go1") {
+			return f
+		}
+	}
+	return s
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,104 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const plainVersionOutput = `name: sekai
+server_name: sekaid
+version: v0.4.1
+commit: 3f2a1c9
+build_tags: netgo,ledger
+go: go version go1.21.6 linux/amd64
+build_deps:
+- github.com/cosmos/cosmos-sdk@v0.47.5
+cosmos_sdk_version: v0.47.5
+`
+
+const jsonVersionOutput = `{"name":"sekai","server_name":"sekaid","version":"0.4.1","commit":"3f2a1c9","go":"go version go1.21.6 linux/amd64","build_deps":["github.com/cosmos/cosmos-sdk@v0.47.5"]}`
+
+// fakeBinary writes a sekaid stand-in that prints body for `version --long`.
+func fakeBinary(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries are shell scripts")
+	}
+	bin := filepath.Join(t.TempDir(), SEKAID_BIN_NAME)
+	script := "#!/bin/sh\n" +
+		`[ "$1 $2" = "version --long" ] || { echo "unexpected args: $*" >&2; exit 2; }` + "\n" +
+		body + "\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+func TestParseBuildInfo(t *testing.T) {
+	want := BuildInfo{Version: "v0.4.1", Commit: "3f2a1c9", GoVersion: "go1.21.6"}
+	info, err := parseBuildInfo([]byte(plainVersionOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *info != want {
+		t.Fatalf("plain: %+v, want %+v", *info, want)
+	}
+
+	want.Version = "0.4.1"
+	if info, err = parseBuildInfo([]byte(jsonVersionOutput)); err != nil {
+		t.Fatal(err)
+	}
+	if *info != want {
+		t.Fatalf("json: %+v, want %+v", *info, want)
+	}
+
+	if _, err := parseBuildInfo([]byte("commit: 3f2a1c9\n")); err == nil {
+		t.Fatal("output without version accepted")
+	}
+	if _, err := parseBuildInfo([]byte("{not json")); err == nil {
+		t.Fatal("broken json accepted")
+	}
+}
+
+func TestVerifyBinary(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		version  string
+		mismatch bool
+		fails    bool
+	}{
+		{name: "plain", body: "cat <<'EOF'\n" + plainVersionOutput + "EOF", version: "v0.4.1"},
+		{name: "json", body: "echo '" + jsonVersionOutput + "'", version: "v0.4.1"},
+		{name: "stderr", body: "echo 'version: 0.4.1' >&2\necho 'commit: 3f2a1c9' >&2", version: "0.4.1"},
+		{name: "build metadata", body: "echo 'version: v0.4.1+ledger'", version: "v0.4.1"},
+		{name: "mismatch", body: "echo 'version: v0.4.2'", version: "v0.4.1", mismatch: true},
+		{name: "prerelease mismatch", body: "echo 'version: v0.4.1-rc.1'", version: "v0.4.1", mismatch: true},
+		{name: "not a version", body: "echo 'version: main'", version: "v0.4.1", mismatch: true},
+		{name: "exit status", body: "echo 'panic: boom' >&2\nexit 1", version: "v0.4.1", fails: true},
+		{name: "no version", body: "echo 'commit: 3f2a1c9'", version: "v0.4.1", fails: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := VerifyBinary(context.Background(), fakeBinary(t, tt.body), tt.version)
+			switch {
+			case tt.mismatch:
+				if !errors.Is(err, ErrVersionMismatch) {
+					t.Fatalf("expected ErrVersionMismatch, got %v", err)
+				}
+			case tt.fails:
+				if err == nil || errors.Is(err, ErrVersionMismatch) {
+					t.Fatalf("expected a run error, got %v", err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case info.Version == "":
+				t.Fatal("empty version")
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/gitres"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/verify"
+)
+
+// GitHub installs release assets of KiraCore/sekai or any fork of it.
+type GitHub struct {
+	Owner string
+	Repo  string
+	// API serves release metadata, Client downloads the assets.
+	API     *gitres.Client
+	Client  *http.Client
+	Options InstallOptions
+}
+
+func (g *GitHub) Source() string {
+	return SOURCE_GITHUB + ":" + g.Owner + "/" + g.Repo
+}
+
+func (g *GitHub) Reusable() bool { return true }
+
+// Resolve picks the release tag matching version, which may be a constraint.
+func (g *GitHub) Resolve(ctx context.Context, version string) (string, error) {
+	return g.API.ResolveVersion(ctx, g.Owner, g.Repo, version, gitres.ResolveOptions{
+		IncludePrerelease: g.Options.IncludePrerelease,
+		Verbose:           g.Options.Verbose,
+	})
+}
+
+// Install makes sure sekaid of the given release tag is present in binDir.
+// The release asset is checked against the release's checksum file (and its signature
+// when trusted keys are given) before anything is extracted; on any failure binDir is
+// left without a binary. Nothing is downloaded if the version is already installed.
+func (g *GitHub) Install(ctx context.Context, version, binDir string) (*InstallResult, error) {
+	if version == "" {
+		return nil, errors.New("sekaid version is empty")
+	}
+	binPath := filepath.Join(binDir, SEKAID_BIN_NAME)
+	if st, err := os.Stat(binPath); err == nil && st.Mode().IsRegular() {
+		digest, _ := os.ReadFile(filepath.Join(binDir, DIGEST_FILE_NAME))
+		return &InstallResult{BinaryPath: binPath, AssetDigest: strings.TrimSpace(string(digest))}, nil
+	}
+
+	return inBinDir(binDir, func() (*InstallResult, error) {
+		assets, err := g.API.GetReleaseAssets(ctx, g.Owner, g.Repo, version)
+		if err != nil {
+			return nil, err
+		}
+		asset, candidates, err := gitres.SelectAsset(assets, g.Options.AssetRules)
+		if err != nil {
+			return nil, fmt.Errorf("tag %s: %w", version, err)
+		}
+		if g.Options.Verbose {
+			for _, c := range candidates {
+				if c.Reason != "" {
+					fmt.Printf("Skipped asset %s: %s\n", c.Name, c.Reason)
+				}
+			}
+			fmt.Println("Selected asset:", asset.Name)
+		}
+
+		client := g.API.AssetClient(g.Client)
+		wantDigest, err := releaseChecksum(ctx, client, assets, asset.Name, g.Options)
+		if err != nil {
+			return nil, err
+		}
+
+		assetPath, err := downloadAsset(ctx, client, asset.URL, asset.Name, binDir, g.Options)
+		if err != nil {
+			return nil, err
+		}
+		defer removeAsset(assetPath, g.Options)
+
+		gotDigest, err := verify.FileSHA256(assetPath)
+		if err != nil {
+			return nil, err
+		}
+		if wantDigest != "" && !strings.EqualFold(wantDigest, gotDigest) {
+			return nil, fmt.Errorf("%w for %s: want %s, got %s", verify.ErrChecksumMismatch, asset.Name, wantDigest, gotDigest)
+		}
+		if g.Options.Verbose && wantDigest != "" {
+			fmt.Println("Verified sha256:", gotDigest)
+		}
+
+		out, err := extractBinary(assetPath, binDir)
+		if err != nil {
+			return nil, err
+		}
+		// a digest that was not checked against the release is not recorded
+		var digest string
+		if wantDigest != "" {
+			digest = "sha256:" + gotDigest
+		}
+		if err := writeDigest(binDir, digest); err != nil {
+			_ = os.Remove(out)
+			return nil, err
+		}
+		return &InstallResult{BinaryPath: out, AssetDigest: digest}, nil
+	})
+}
@@ -0,0 +1,141 @@
+// Package archive extracts files from release assets: .deb packages, tarballs
+// (plain, gzip, xz, zstd), zip files and raw binaries. The format is detected from
+// the content, file names are never trusted for it.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Format of an asset as detected by Detect.
+type Format string
+
+const (
+	FormatDeb    Format = "deb"
+	FormatTar    Format = "tar"
+	FormatZip    Format = "zip"
+	FormatBinary Format = "binary"
+)
+
+var ErrUnknownFormat = errors.New("unknown asset format")
+
+var (
+	magicAr     = []byte("!<arch>\n")
+	magicZip    = []byte("PK\x03\x04")
+	magicZipEnd = []byte("PK\x05\x06")
+	magicUstar  = []byte("ustar")
+	// executables: ELF, PE, scripts and the Mach-O variants (32/64 bit, both byte orders, universal)
+	magicBinaries = [][]byte{
+		[]byte("\x7fELF"), []byte("MZ"), []byte("#!"),
+		{0xfe, 0xed, 0xfa, 0xce}, {0xfe, 0xed, 0xfa, 0xcf},
+		{0xce, 0xfa, 0xed, 0xfe}, {0xcf, 0xfa, 0xed, 0xfe},
+		{0xca, 0xfe, 0xba, 0xbe},
+	}
+)
+
+// Detect reads the first bytes of path and tells its format.
+func Detect(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head, err := bufio.NewReader(f).Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return detect(head, filepath.Base(path))
+}
+
+func detect(head []byte, name string) (Format, error) {
+	switch {
+	case bytes.HasPrefix(head, magicAr):
+		return FormatDeb, nil
+	case bytes.HasPrefix(head, magicZip), bytes.HasPrefix(head, magicZipEnd):
+		return FormatZip, nil
+	case bytes.HasPrefix(head, magicGzip), bytes.HasPrefix(head, magicXz), bytes.HasPrefix(head, magicZstd):
+		return FormatTar, nil
+	case len(head) >= 262 && bytes.Equal(head[257:262], magicUstar):
+		return FormatTar, nil
+	}
+	for _, m := range magicBinaries {
+		if bytes.HasPrefix(head, m) {
+			return FormatBinary, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+}
+
+// Extract takes what spec asks for out of the asset at path, whatever its format.
+// A raw binary is a single file and can only be "extracted" as the first of
+// spec.Basenames.
+func Extract(path, destDir string, spec Spec) (*Result, error) {
+	format, err := Detect(path)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatDeb:
+		return extractDeb(path, destDir, spec)
+	case FormatZip:
+		return extractZip(path, destDir, spec)
+	case FormatBinary:
+		return extractRaw(path, destDir, spec)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ExtractTar(f, destDir, spec)
+}
+
+// ExtractBinary extracts the first file whose basename matches any name in basenames
+// into destDir/<basename> and returns its path. A raw binary asset is written as
+// basenames[0].
+func ExtractBinary(path string, basenames []string, destDir string) (string, error) {
+	res, err := Extract(path, destDir, Spec{Basenames: basenames, Any: true})
+	if err != nil {
+		return "", err
+	}
+	for _, want := range basenames {
+		if p, ok := res.Files[want]; ok {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("none of %v found in %s", basenames, filepath.Base(path))
+}
+
+// extractRaw copies a bare executable; it has no mode of its own, so it gets 0755.
+func extractRaw(path, destDir string, spec Spec) (*Result, error) {
+	if len(spec.Basenames) == 0 || len(spec.Subtrees) > 0 {
+		return nil, fmt.Errorf("%s is a single binary, it can only be extracted by one basename", filepath.Base(path))
+	}
+	if !spec.Any && len(spec.Basenames) > 1 {
+		return nil, fmt.Errorf("%s is a single binary, it can not provide %v", filepath.Base(path), spec.Basenames)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return nil, err
+	}
+	out := filepath.Join(destDir, spec.Basenames[0])
+	e := &entry{name: filepath.Base(path), kind: kindFile, mode: 0o755, size: st.Size()}
+	if err := writeFile(f, e, out, spec.Limits.orDefault()); err != nil {
+		return nil, err
+	}
+	return &Result{Files: map[string]string{spec.Basenames[0]: out}, Dirs: map[string]string{}}, nil
+}
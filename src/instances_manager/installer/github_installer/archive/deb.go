@@ -0,0 +1,35 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/blakesmith/ar"
+)
+
+// extractDeb streams the data.tar.* member of a .deb (ar archive) through the tar
+// walker; nothing is buffered in memory.
+func extractDeb(debPath, destDir string, spec Spec) (*Result, error) {
+	f, err := os.Open(debPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	arR := ar.NewReader(f)
+	for {
+		hdr, err := arR.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("data.tar.* not found in %s", debPath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(hdr.Name, "data.tar") {
+			return ExtractTar(io.LimitReader(arR, hdr.Size), destDir, spec)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrUnsafeEntry is returned for archives with path traversal, escaping links or
+	// device files. The whole archive is rejected, not just the entry.
+	ErrUnsafeEntry = errors.New("unsafe archive entry")
+	ErrTooLarge    = errors.New("archive exceeds size limit")
+)
+
+// Limits bound what an archive may make us read and write.
+type Limits struct {
+	// MaxFileSize caps every extracted file.
+	MaxFileSize int64
+	// MaxTotalSize caps the bytes written for all entries together, and for tar
+	// also the decompressed stream, extracted or not.
+	MaxTotalSize int64
+	// MaxEntries caps the number of archive entries.
+	MaxEntries int
+}
+
+// DefaultLimits are used for every zero field of Spec.Limits.
+var DefaultLimits = Limits{
+	MaxFileSize:  1 << 30,
+	MaxTotalSize: 4 << 30,
+	MaxEntries:   100000,
+}
+
+// Spec selects what to extract in a single pass.
+type Spec struct {
+	// Basenames are regular files matched by basename anywhere in the archive, the
+	// first match of each is written to destDir/<basename>.
+	Basenames []string
+	// Any stops at the first file matching one of Basenames and does not require the others.
+	Any bool
+	// Subtrees are archive directories such as "usr/lib/sekai"; everything below one is
+	// written to destDir/<last element>/..., keeping relative symlinks that stay inside it.
+	Subtrees []string
+	Limits   Limits
+}
+
+// Result maps what was requested to where it was written.
+type Result struct {
+	// Files maps a basename to its extracted path.
+	Files map[string]string
+	// Dirs maps a subtree to its extracted directory.
+	Dirs map[string]string
+}
+
+type kind int
+
+const (
+	kindFile kind = iota
+	kindDir
+	kindSymlink
+	kindHardlink
+	kindMeta
+	kindOther
+)
+
+// entry is an archive member independent of the archive format.
+type entry struct {
+	name     string
+	kind     kind
+	linkname string
+	mode     os.FileMode
+	size     int64
+	// typ describes kindOther entries in errors
+	typ string
+}
+
+// walker yields entries in archive order; open returns the content of the current one.
+type walker interface {
+	next() (*entry, error)
+	open() (io.ReadCloser, error)
+}
+
+// extract walks the archive once and writes what spec asks for into destDir.
+// File modes come from the archive without setuid/setgid/sticky bits. On error,
+// everything written so far is removed again.
+func extract(w walker, destDir string, spec Spec) (res *Result, err error) {
+	limits := spec.Limits.orDefault()
+	subtrees := make([]string, 0, len(spec.Subtrees))
+	for _, s := range spec.Subtrees {
+		clean, err := cleanName(s)
+		if err != nil || clean == "." {
+			return nil, fmt.Errorf("invalid subtree %q", s)
+		}
+		subtrees = append(subtrees, clean)
+	}
+
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return nil, err
+	}
+	res = &Result{Files: map[string]string{}, Dirs: map[string]string{}}
+	var written []string
+	defer func() {
+		if err != nil {
+			for i := len(written) - 1; i >= 0; i-- {
+				_ = os.RemoveAll(written[i])
+			}
+			res = nil
+		}
+	}()
+
+	done := func() bool {
+		if len(subtrees) > 0 || len(res.Files) == 0 {
+			return false
+		}
+		return spec.Any || len(res.Files) == len(spec.Basenames)
+	}
+	var links []string
+	// shared by all entries so the total is capped whatever the archive format
+	total := limits.MaxTotalSize
+	for entries := 0; !done(); entries++ {
+		e, err := w.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entries >= limits.MaxEntries {
+			return nil, fmt.Errorf("%w: more than %d entries", ErrTooLarge, limits.MaxEntries)
+		}
+		name, err := checkEntry(e)
+		if err != nil {
+			return nil, err
+		}
+
+		if sub, rel, ok := inSubtree(subtrees, name); ok {
+			root := filepath.Join(destDir, path.Base(sub))
+			if _, seen := res.Dirs[sub]; !seen {
+				if err := os.MkdirAll(root, 0o755); err != nil {
+					return nil, err
+				}
+				res.Dirs[sub] = root
+				written = append(written, root)
+			}
+			if err := writeEntry(w, e, root, rel, limits, &total); err != nil {
+				return nil, err
+			}
+			if e.kind == kindSymlink {
+				links = append(links, filepath.Join(root, filepath.FromSlash(rel)))
+			}
+			continue
+		}
+
+		if e.kind != kindFile {
+			continue
+		}
+		base := path.Base(name)
+		for _, want := range spec.Basenames {
+			if base != want {
+				continue
+			}
+			if _, done := res.Files[want]; done {
+				break
+			}
+			out := filepath.Join(destDir, want)
+			if err := writeOpened(w, e, out, limits, &total); err != nil {
+				return nil, err
+			}
+			res.Files[want] = out
+			written = append(written, out)
+			break
+		}
+	}
+
+	if err := checkLinks(destDir, links); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	if !spec.Any || len(res.Files) == 0 {
+		for _, want := range spec.Basenames {
+			if _, ok := res.Files[want]; !ok {
+				missing = append(missing, want)
+			}
+		}
+	}
+	for _, sub := range subtrees {
+		if _, ok := res.Dirs[sub]; !ok {
+			missing = append(missing, sub+"/")
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%v not found in archive", missing)
+	}
+	return res, nil
+}
+
+// checkEntry rejects names leaving the archive root, links pointing out of it and
+// anything that is not a file, directory or link. It returns the cleaned name.
+func checkEntry(e *entry) (string, error) {
+	name, err := cleanName(e.name)
+	if err != nil {
+		return "", err
+	}
+	switch e.kind {
+	case kindSymlink:
+		if path.IsAbs(e.linkname) {
+			return "", fmt.Errorf("%w: symlink %s points to absolute %s", ErrUnsafeEntry, e.name, e.linkname)
+		}
+		if _, err := cleanName(path.Join(path.Dir(name), e.linkname)); err != nil {
+			return "", fmt.Errorf("%w: symlink %s escapes to %s", ErrUnsafeEntry, e.name, e.linkname)
+		}
+	case kindHardlink:
+		if _, err := cleanName(e.linkname); err != nil {
+			return "", fmt.Errorf("%w: hard link %s to %s", ErrUnsafeEntry, e.name, e.linkname)
+		}
+	case kindOther:
+		return "", fmt.Errorf("%w: %s has type %s", ErrUnsafeEntry, e.name, e.typ)
+	}
+	return name, nil
+}
+
+// cleanName turns an archive name into a clean relative slash path inside the root.
+func cleanName(name string) (string, error) {
+	if path.IsAbs(name) || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeEntry, name)
+	}
+	clean := path.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: %s leaves the archive root", ErrUnsafeEntry, name)
+	}
+	return clean, nil
+}
+
+func inSubtree(subtrees []string, name string) (sub, rel string, ok bool) {
+	for _, s := range subtrees {
+		if name == s {
+			return s, ".", true
+		}
+		if rest, found := strings.CutPrefix(name, s+"/"); found {
+			return s, rest, true
+		}
+	}
+	return "", "", false
+}
+
+// checkLinks resolves every extracted symlink once the whole archive is written;
+// links may only point at something that exists inside the subtree they belong to.
+// writeEntry checks each link against the links before it; this catches a link
+// that a later entry turned into an escape.
+func checkLinks(destDir string, links []string) error {
+	for _, link := range links {
+		rel, err := filepath.Rel(destDir, link)
+		if err != nil {
+			return err
+		}
+		root, err := filepath.EvalSymlinks(filepath.Join(destDir, strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]))
+		if err != nil {
+			return err
+		}
+		target, err := filepath.EvalSymlinks(link)
+		if err != nil {
+			return fmt.Errorf("%w: symlink %s does not resolve: %v", ErrUnsafeEntry, rel, err)
+		}
+		if target != root && !strings.HasPrefix(target, root+string(filepath.Separator)) {
+			return fmt.Errorf("%w: symlink %s resolves outside of its subtree", ErrUnsafeEntry, rel)
+		}
+	}
+	return nil
+}
+
+// noLinkedParents makes sure writing root/rel does not go through a symlink
+// created by an earlier entry.
+func noLinkedParents(root, rel string) error {
+	dir := root
+	for _, p := range strings.Split(path.Dir(rel), "/") {
+		if p == "." {
+			continue
+		}
+		dir = filepath.Join(dir, p)
+		st, err := os.Lstat(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if st.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s is written through a symlink", ErrUnsafeEntry, rel)
+		}
+	}
+	return nil
+}
+
+// maxLinkHops bounds resolveInRoot like the kernel bounds symlink loops.
+const maxLinkHops = 40
+
+// resolveInRoot resolves the slash path rel below root component by component,
+// following symlinks that already exist below root the way the kernel would.
+// Missing components are taken as they are. It fails as soon as the path would
+// leave root, and returns the resolved path relative to root.
+func resolveInRoot(root, rel string) (string, error) {
+	var resolved []string
+	pending := strings.Split(rel, "/")
+	for hops := 0; len(pending) > 0; {
+		p := pending[0]
+		pending = pending[1:]
+		switch p {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", errors.New("path leaves the root")
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+		cur := filepath.Join(root, filepath.FromSlash(path.Join(append(resolved, p)...)))
+		st, err := os.Lstat(cur)
+		if err != nil || st.Mode()&os.ModeSymlink == 0 {
+			resolved = append(resolved, p)
+			continue
+		}
+		if hops++; hops > maxLinkHops {
+			return "", errors.New("too many levels of symbolic links")
+		}
+		target, err := os.Readlink(cur)
+		if err != nil {
+			return "", err
+		}
+		if path.IsAbs(target) {
+			return "", fmt.Errorf("%s points to absolute %s", p, target)
+		}
+		// a relative target continues from the directory holding the link
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+	return path.Join(resolved...), nil
+}
+
+// writeEntry writes one entry of a subtree below root. Symlinks must stay inside
+// the subtree since that is all that gets extracted.
+func writeEntry(w walker, e *entry, root, rel string, limits Limits, total *int64) error {
+	if err := noLinkedParents(root, rel); err != nil {
+		return err
+	}
+	out := filepath.Join(root, filepath.FromSlash(rel))
+	switch e.kind {
+	case kindDir:
+		return os.MkdirAll(out, 0o755)
+	case kindFile:
+		return writeOpened(w, e, out, limits, total)
+	case kindSymlink:
+		// resolved through the links written so far, so a chain of links that each
+		// look harmless on their own can not point out of the subtree
+		if _, err := resolveInRoot(root, path.Dir(rel)+"/"+e.linkname); err != nil {
+			return fmt.Errorf("%w: symlink %s leaves the extracted subtree: %v", ErrUnsafeEntry, e.name, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+			return err
+		}
+		_ = os.Remove(out)
+		return os.Symlink(e.linkname, out)
+	case kindHardlink:
+		return fmt.Errorf("%w: hard link %s in extracted subtree", ErrUnsafeEntry, e.name)
+	}
+	return nil
+}
+
+// writeOpened writes the current entry, charging its bytes to total.
+func writeOpened(w walker, e *entry, out string, limits Limits, total *int64) error {
+	if e.size > limits.MaxFileSize {
+		return fmt.Errorf("%w: %s is %d bytes", ErrTooLarge, e.name, e.size)
+	}
+	if e.size > *total {
+		return fmt.Errorf("%w: %s exceeds the total size limit", ErrTooLarge, e.name)
+	}
+	r, err := w.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return writeFile(&limitedReader{r: r, left: total}, e, out, limits)
+}
+
+// writeFile copies an entry to out through a fresh temporary file in the same
+// directory, within the size limit. The temporary file is created exclusively
+// so it can not be a link planted by an earlier entry, and out itself must not
+// be a link or directory.
+func writeFile(r io.Reader, e *entry, out string, limits Limits) error {
+	if e.size > limits.MaxFileSize {
+		return fmt.Errorf("%w: %s is %d bytes", ErrTooLarge, e.name, e.size)
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	if st, err := os.Lstat(out); err == nil && !st.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s would replace a %s", ErrUnsafeEntry, e.name, st.Mode().Type())
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	perm := e.mode & os.ModePerm
+	f, err := os.CreateTemp(filepath.Dir(out), "."+filepath.Base(out)+".*.partial")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	n, err := io.Copy(f, io.LimitReader(r, limits.MaxFileSize+1))
+	if err == nil && n > limits.MaxFileSize {
+		err = fmt.Errorf("%w: %s is larger than %d bytes", ErrTooLarge, e.name, limits.MaxFileSize)
+	}
+	// CreateTemp uses 0600, set the archive's permission bits on the open file
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (l Limits) orDefault() Limits {
+	if l.MaxFileSize <= 0 {
+		l.MaxFileSize = DefaultLimits.MaxFileSize
+	}
+	if l.MaxTotalSize <= 0 {
+		l.MaxTotalSize = DefaultLimits.MaxTotalSize
+	}
+	if l.MaxEntries <= 0 {
+		l.MaxEntries = DefaultLimits.MaxEntries
+	}
+	return l
+}
@@ -0,0 +1,198 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	name string
+	typ  byte
+	link string
+	body string
+}
+
+func makeTar(t *testing.T, entries ...tarEntry) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		typ := e.typ
+		if typ == 0 {
+			typ = tar.TypeReg
+		}
+		h := &tar.Header{Name: e.name, Typeflag: typ, Linkname: e.link, Mode: 0o755}
+		if typ == tar.TypeReg {
+			h.Size = int64(len(e.body))
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+// sandbox returns destDir inside a parent directory that must stay untouched.
+func sandbox(t *testing.T) (parent, destDir string) {
+	parent = t.TempDir()
+	return parent, filepath.Join(parent, "dest")
+}
+
+func assertNoEscape(t *testing.T, parent string) {
+	t.Helper()
+	err := filepath.Walk(parent, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(parent, p)
+		if rel != "." && rel != "dest" && !strings.HasPrefix(rel, "dest"+string(filepath.Separator)) {
+			t.Errorf("%s was written outside of destDir", rel)
+		}
+		if strings.Contains(info.Name(), "pwned") && info.Mode().IsRegular() {
+			t.Errorf("%s was written", rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractTar(t *testing.T) {
+	_, dest := sandbox(t)
+	src := makeTar(t,
+		tarEntry{name: "usr/bin/sekaid", body: "binary"},
+		tarEntry{name: "usr/lib/sekai/", typ: tar.TypeDir},
+		tarEntry{name: "usr/lib/sekai/lib.so.1", body: "lib"},
+		tarEntry{name: "usr/lib/sekai/lib.so", typ: tar.TypeSymlink, link: "lib.so.1"},
+		tarEntry{name: "usr/lib/sekai/sub/up", typ: tar.TypeSymlink, link: "../lib.so.1"},
+	)
+	res, err := ExtractTar(src, dest, Spec{Basenames: []string{"sekaid"}, Subtrees: []string{"usr/lib/sekai"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(res.Files["sekaid"]); err != nil || string(b) != "binary" {
+		t.Errorf("sekaid: %q, %v", b, err)
+	}
+	for _, link := range []string{"lib.so", "sub/up"} {
+		if b, err := os.ReadFile(filepath.Join(res.Dirs["usr/lib/sekai"], link)); err != nil || string(b) != "lib" {
+			t.Errorf("%s: %q, %v", link, b, err)
+		}
+	}
+}
+
+func TestExtractTarUnsafe(t *testing.T) {
+	subtree := Spec{Subtrees: []string{"lib"}}
+	cases := []struct {
+		name    string
+		spec    Spec
+		entries []tarEntry
+	}{
+		{"traversal", Spec{Basenames: []string{"pwned"}}, []tarEntry{
+			{name: "../pwned", body: "x"},
+		}},
+		{"nested traversal", subtree, []tarEntry{
+			{name: "lib/a/../../../pwned", body: "x"},
+		}},
+		{"absolute name", Spec{Basenames: []string{"pwned"}}, []tarEntry{
+			{name: "/tmp/pwned", body: "x"},
+		}},
+		{"absolute link", subtree, []tarEntry{
+			{name: "lib/l", typ: tar.TypeSymlink, link: "/etc"},
+		}},
+		{"escaping link", subtree, []tarEntry{
+			{name: "lib/l", typ: tar.TypeSymlink, link: "../.."},
+		}},
+		{"link out of the subtree", subtree, []tarEntry{
+			{name: "lib/l", typ: tar.TypeSymlink, link: "../other"},
+		}},
+		{"chained links", subtree, []tarEntry{
+			{name: "lib/s", typ: tar.TypeSymlink, link: "."},
+			{name: "lib/t1", typ: tar.TypeSymlink, link: "s/.."},
+			{name: "lib/t2", typ: tar.TypeSymlink, link: "t1/.."},
+			{name: "lib/x.partial", typ: tar.TypeSymlink, link: "t2/pwned"},
+			{name: "lib/x", body: "x"},
+		}},
+		{"link turned into an escape later", subtree, []tarEntry{
+			{name: "lib/a", typ: tar.TypeSymlink, link: "b/.."},
+			{name: "lib/b", typ: tar.TypeSymlink, link: "."},
+		}},
+		{"write through a linked parent", subtree, []tarEntry{
+			{name: "lib/d", typ: tar.TypeSymlink, link: "."},
+			{name: "lib/d/pwned", body: "x"},
+		}},
+		{"file replacing a link", subtree, []tarEntry{
+			{name: "lib/x", typ: tar.TypeSymlink, link: "y"},
+			{name: "lib/x", body: "x"},
+		}},
+		{"hard link in subtree", subtree, []tarEntry{
+			{name: "lib/y", body: "x"},
+			{name: "lib/x", typ: tar.TypeLink, link: "lib/y"},
+		}},
+		{"char device", subtree, []tarEntry{
+			{name: "lib/tty", typ: tar.TypeChar},
+		}},
+		{"block device", subtree, []tarEntry{
+			{name: "lib/sda", typ: tar.TypeBlock},
+		}},
+		{"fifo", subtree, []tarEntry{
+			{name: "lib/fifo", typ: tar.TypeFifo},
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			parent, dest := sandbox(t)
+			_, err := ExtractTar(makeTar(t, c.entries...), dest, c.spec)
+			if !errors.Is(err, ErrUnsafeEntry) {
+				t.Fatalf("got %v, want %v", err, ErrUnsafeEntry)
+			}
+			assertNoEscape(t, parent)
+			if entries, _ := os.ReadDir(dest); len(entries) != 0 {
+				t.Errorf("destDir not cleaned up: %v", entries)
+			}
+		})
+	}
+}
+
+func TestExtractTarLimits(t *testing.T) {
+	big := strings.Repeat("x", 1024)
+	cases := []struct {
+		name    string
+		limits  Limits
+		entries []tarEntry
+	}{
+		{"file size", Limits{MaxFileSize: 100}, []tarEntry{
+			{name: "lib/big", body: big},
+		}},
+		{"total size", Limits{MaxTotalSize: 2048}, []tarEntry{
+			{name: "lib/a", body: big},
+			{name: "lib/b", body: big},
+			{name: "lib/c", body: big},
+		}},
+		{"entries", Limits{MaxEntries: 2}, []tarEntry{
+			{name: "lib/a", body: "a"},
+			{name: "lib/b", body: "b"},
+			{name: "lib/c", body: "c"},
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, dest := sandbox(t)
+			_, err := ExtractTar(makeTar(t, c.entries...), dest, Spec{Subtrees: []string{"lib"}, Limits: c.limits})
+			if !errors.Is(err, ErrTooLarge) {
+				t.Fatalf("got %v, want %v", err, ErrTooLarge)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// ExtractTar reads a (possibly gzip, xz or zstd compressed) tar stream and extracts
+// what spec asks for into destDir. The compression is detected by magic bytes.
+func ExtractTar(src io.Reader, destDir string, spec Spec) (*Result, error) {
+	r, closer, err := decompress(src)
+	if err != nil {
+		return nil, err
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+	left := spec.Limits.orDefault().MaxTotalSize
+	counted := &limitedReader{r: r, left: &left}
+	return extract(&tarWalker{tr: tar.NewReader(counted)}, destDir, spec)
+}
+
+type tarWalker struct {
+	tr *tar.Reader
+}
+
+func (t *tarWalker) next() (*entry, error) {
+	h, err := t.tr.Next()
+	if err != nil {
+		return nil, err
+	}
+	e := &entry{name: h.Name, linkname: h.Linkname, mode: os.FileMode(h.Mode), size: h.Size}
+	switch h.Typeflag {
+	case tar.TypeReg:
+		e.kind = kindFile
+	case tar.TypeDir:
+		e.kind = kindDir
+	case tar.TypeSymlink:
+		e.kind = kindSymlink
+	case tar.TypeLink:
+		e.kind = kindHardlink
+	case tar.TypeXGlobalHeader:
+		e.kind = kindMeta
+	default:
+		e.kind, e.typ = kindOther, fmt.Sprintf("%q", h.Typeflag)
+	}
+	return e, nil
+}
+
+func (t *tarWalker) open() (io.ReadCloser, error) {
+	return io.NopCloser(t.tr), nil
+}
+
+var (
+	magicGzip = []byte{0x1f, 0x8b}
+	magicXz   = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	magicZstd = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompress detects gzip, xz and zstd by magic bytes, anything else is read as plain tar.
+// The returned io.Closer (if non-nil) should be closed by the caller.
+func decompress(src io.Reader) (io.Reader, io.Closer, error) {
+	br := bufio.NewReader(src)
+	head, _ := br.Peek(len(magicXz))
+	switch {
+	case bytes.HasPrefix(head, magicGzip):
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gzip open: %w", err)
+		}
+		return gzr, gzr, nil
+	case bytes.HasPrefix(head, magicXz):
+		xzr, err := xz.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("xz open: %w", err)
+		}
+		// xz.Reader doesn't implement io.Closer
+		return xzr, nil, nil
+	case bytes.HasPrefix(head, magicZstd):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("zstd open: %w", err)
+		}
+		return zr, zr.IOReadCloser(), nil
+	}
+	return br, nil, nil
+}
+
+// limitedReader fails instead of returning EOF once more than *left bytes were
+// read; several readers may share one budget.
+type limitedReader struct {
+	r    io.Reader
+	left *int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if *l.left < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > *l.left+1 {
+		p = p[:*l.left+1]
+	}
+	n, err := l.r.Read(p)
+	*l.left -= int64(n)
+	if *l.left < 0 {
+		return n, fmt.Errorf("%w: data is larger than the total size limit", ErrTooLarge)
+	}
+	return n, err
+}
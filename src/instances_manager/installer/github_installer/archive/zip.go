@@ -0,0 +1,82 @@
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// maxLinkSize bounds the content of a zip symlink entry, which is its target.
+	maxLinkSize = 4096
+
+	// zip "version made by" hosts that store unix modes
+	creatorUnix   = 3
+	creatorDarwin = 19
+)
+
+// extractZip walks the zip central directory; entries are read one at a time.
+func extractZip(path, destDir string, spec Spec) (*Result, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return extract(&zipWalker{files: zr.File, i: -1}, destDir, spec)
+}
+
+type zipWalker struct {
+	files []*zip.File
+	i     int
+}
+
+func (z *zipWalker) next() (*entry, error) {
+	z.i++
+	if z.i >= len(z.files) {
+		return nil, io.EOF
+	}
+	f := z.files[z.i]
+	mode := f.Mode()
+	e := &entry{name: f.Name, mode: mode, size: int64(f.UncompressedSize64)}
+	switch {
+	case mode.IsRegular():
+		e.kind = kindFile
+		if host := f.CreatorVersion >> 8; host != creatorUnix && host != creatorDarwin {
+			// no unix mode recorded, treat files like raw binaries
+			e.mode = 0o755
+		}
+	case mode.IsDir():
+		e.kind = kindDir
+	case mode&os.ModeSymlink != 0:
+		e.kind = kindSymlink
+		target, err := z.readLink(f)
+		if err != nil {
+			return nil, err
+		}
+		e.linkname = target
+	default:
+		e.kind, e.typ = kindOther, mode.Type().String()
+	}
+	return e, nil
+}
+
+func (z *zipWalker) open() (io.ReadCloser, error) {
+	return z.files[z.i].Open()
+}
+
+func (z *zipWalker) readLink(f *zip.File) (string, error) {
+	if f.UncompressedSize64 > maxLinkSize {
+		return "", fmt.Errorf("%w: symlink %s has a %d byte target", ErrUnsafeEntry, f.Name, f.UncompressedSize64)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	b, err := io.ReadAll(io.LimitReader(r, maxLinkSize))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func makeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "a.zip")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestExtractZipTotalSize(t *testing.T) {
+	big := strings.Repeat("x", 1024)
+	src := makeZip(t, map[string]string{"lib/a": big, "lib/b": big, "lib/c": big})
+
+	spec := Spec{Subtrees: []string{"lib"}, Limits: Limits{MaxTotalSize: 2048}}
+	if _, err := extractZip(src, filepath.Join(t.TempDir(), "dest"), spec); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("got %v, want %v", err, ErrTooLarge)
+	}
+
+	spec.Limits.MaxTotalSize = 3 * 1024
+	res, err := extractZip(src, filepath.Join(t.TempDir(), "dest"), spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(res.Dirs["lib"], "c")); err != nil || len(b) != 1024 {
+		t.Errorf("lib/c: %d bytes, %v", len(b), err)
+	}
+}
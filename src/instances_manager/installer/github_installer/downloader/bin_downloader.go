@@ -4,35 +4,300 @@ package downloader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const (
+	DefaultMaxRetries     = 5
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+
+	// progressInterval throttles progress callbacks.
+	progressInterval = 200 * time.Millisecond
+
+	// validatorSuffix names the file next to a .partial that holds the ETag or
+	// Last-Modified of the response it was started from.
+	validatorSuffix = ".validator"
+)
+
+// Progress is reported while a download runs. Total is -1 when the size is unknown.
+type Progress struct {
+	Downloaded     int64
+	Total          int64
+	BytesPerSecond float64
+	Done           bool
+}
+
+// Options controls Download. Zero values select the defaults.
+type Options struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Progress       func(Progress)
+}
+
+func (o *Options) setDefaults() {
+	if o.MaxRetries == 0 {
+		o.MaxRetries = DefaultMaxRetries
+	}
+	if o.InitialBackoff == 0 {
+		o.InitialBackoff = DefaultInitialBackoff
+	}
+	if o.MaxBackoff == 0 {
+		o.MaxBackoff = DefaultMaxBackoff
+	}
+}
+
+// retryableError marks failures worth another attempt (network errors, 5xx, 429).
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
 func setUA(req *http.Request) {
 	req.Header.Set("User-Agent", "sekaifetch/1.0 (+https://example)")
 }
 
-// DownloadToFile streams a URL to a local path. Follows redirects by default via http.Client.
+// DownloadToFile streams a URL to a local path with the default retry policy.
+// Follows redirects by default via http.Client.
 func DownloadToFile(ctx context.Context, client *http.Client, url, path string) error {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return Download(ctx, client, url, path, Options{})
+}
+
+// Download streams a URL into <path>.partial and renames it to path once complete,
+// so an interrupted download never looks like a finished file. A leftover .partial,
+// also one from an earlier run, is resumed with an HTTP Range request guarded by
+// If-Range, so the server sends the whole file again if it changed in between.
+// Transient failures are retried with exponential backoff.
+func Download(ctx context.Context, client *http.Client, url, path string, opts Options) error {
+	opts.setDefaults()
+	partial := path + ".partial"
+
+	backoff := opts.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		err := downloadOnce(ctx, client, url, partial, opts.Progress)
+		if err == nil {
+			_ = os.Remove(partial + validatorSuffix)
+			return os.Rename(partial, path)
+		}
+		var re *retryableError
+		if !errors.As(err, &re) || attempt >= opts.MaxRetries {
+			return err
+		}
+
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+		if re.retryAfter > wait {
+			wait = re.retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if backoff *= 2; backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+}
+
+func downloadOnce(ctx context.Context, client *http.Client, url, partial string, progress func(Progress)) error {
+	var offset int64
+	// a partial file can only be resumed if we know which version of the file it holds
+	validator, _ := os.ReadFile(partial + validatorSuffix)
+	if st, err := os.Stat(partial); err == nil && len(validator) > 0 {
+		offset = st.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
 	setUA(req)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", string(validator))
+	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &retryableError{err: err}
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
+
+	total := int64(-1)
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			// server resumed somewhere else, start over
+			_ = os.Remove(partial)
+			return &retryableError{err: fmt.Errorf("unexpected Content-Range %q", resp.Header.Get("Content-Range"))}
+		}
+		total = size
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		if _, size, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && size == offset {
+			// the partial file is already complete
+			return nil
+		}
+		_ = os.Remove(partial)
+		return &retryableError{err: errors.New("stale partial download")}
+	case resp.StatusCode == http.StatusOK:
+		// fresh download, no range support or the file changed since the partial was written
+		offset = 0
+		total = resp.ContentLength
+		flags |= os.O_TRUNC
+		if err := writeValidator(partial, resp.Header); err != nil {
+			return err
+		}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return &retryableError{
+			err:        fmt.Errorf("download failed: HTTP %d", resp.StatusCode),
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	default:
 		return fmt.Errorf("download failed: HTTP %d", resp.StatusCode)
 	}
 
-	out, err := os.Create(path)
+	out, err := os.OpenFile(partial, flags, 0o644)
 	if err != nil {
 		return err
 	}
 	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
-	return err
+	pw := &progressWriter{done: offset, total: total, start: time.Now(), startBytes: offset, report: progress}
+	n, err := io.Copy(out, io.TeeReader(resp.Body, pw))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &retryableError{err: err}
+	}
+	if total >= 0 && offset+n != total {
+		return &retryableError{err: fmt.Errorf("short download: got %d of %d bytes", offset+n, total)}
+	}
+	pw.finish()
+	return out.Close()
+}
+
+// writeValidator records what a new partial download can be resumed against: a
+// strong ETag or else Last-Modified. Without either the partial is never resumed.
+func writeValidator(partial string, h http.Header) error {
+	v := h.Get("ETag")
+	if v == "" || strings.HasPrefix(v, "W/") {
+		v = h.Get("Last-Modified")
+	}
+	if v == "" {
+		if err := os.Remove(partial + validatorSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(partial+validatorSuffix, []byte(v), 0o644)
+}
+
+// parseContentRange parses "bytes <start>-<end>/<size>" and "bytes */<size>".
+func parseContentRange(h string) (start, size int64, ok bool) {
+	h = strings.TrimSpace(strings.TrimPrefix(h, "bytes"))
+	rng, sz, found := strings.Cut(h, "/")
+	if !found {
+		return 0, 0, false
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(sz), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	rng = strings.TrimSpace(rng)
+	if rng == "*" {
+		return 0, size, true
+	}
+	s, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err = strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
+
+func parseRetryAfter(h string) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+type progressWriter struct {
+	done, total int64
+	start       time.Time
+	startBytes  int64
+	last        time.Time
+	report      func(Progress)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if p.report != nil && time.Since(p.last) >= progressInterval {
+		p.last = time.Now()
+		p.report(p.snapshot(false))
+	}
+	return len(b), nil
+}
+
+func (p *progressWriter) finish() {
+	if p.report != nil {
+		p.report(p.snapshot(true))
+	}
+}
+
+func (p *progressWriter) snapshot(done bool) Progress {
+	rate := 0.0
+	if el := time.Since(p.start).Seconds(); el > 0 {
+		rate = float64(p.done-p.startBytes) / el
+	}
+	return Progress{Downloaded: p.done, Total: p.total, BytesPerSecond: rate, Done: done}
+}
+
+// Fetch reads a small document (checksums, signatures) into memory, refusing more than maxBytes.
+func Fetch(ctx context.Context, client *http.Client, url string, maxBytes int64) ([]byte, error) {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	setUA(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("fetch failed: HTTP %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > maxBytes {
+		return nil, fmt.Errorf("fetch %s: response larger than %d bytes", url, maxBytes)
+	}
+	return b, nil
 }
@@ -0,0 +1,219 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// abortWriter drops the connection once limit bytes of the body are written.
+type abortWriter struct {
+	http.ResponseWriter
+	limit int
+}
+
+func (w *abortWriter) Write(b []byte) (int, error) {
+	if len(b) > w.limit {
+		_, _ = w.ResponseWriter.Write(b[:w.limit])
+		w.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	w.limit -= len(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// assetServer serves content with Range support; etag may be empty. While
+// limit >= 0 every response is cut after limit bytes.
+type assetServer struct {
+	mu       sync.Mutex
+	content  []byte
+	etag     string
+	limit    int
+	requests []http.Header
+}
+
+func newAssetServer(t *testing.T, content []byte, etag string) (*assetServer, *httptest.Server) {
+	t.Helper()
+	a := &assetServer{content: content, etag: etag, limit: -1}
+	srv := httptest.NewServer(a)
+	t.Cleanup(srv.Close)
+	return a, srv
+}
+
+func (a *assetServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	a.requests = append(a.requests, r.Header.Clone())
+	content, etag, limit := a.content, a.etag, a.limit
+	a.mu.Unlock()
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if limit >= 0 {
+		w = &abortWriter{ResponseWriter: w, limit: limit}
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+}
+
+func (a *assetServer) set(f func(a *assetServer)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f(a)
+}
+
+func (a *assetServer) lastRequest() http.Header {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.requests[len(a.requests)-1]
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+var fastRetry = Options{MaxRetries: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+// interruptedRun downloads while the server cuts every response after 20000 bytes.
+func interruptedRun(t *testing.T, a *assetServer, url, path string) {
+	t.Helper()
+	a.set(func(a *assetServer) { a.limit = 20000 })
+	if err := Download(context.Background(), http.DefaultClient, url, path, fastRetry); err == nil {
+		t.Fatal("interrupted download succeeded")
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("interrupted download left a finished file")
+	}
+	a.set(func(a *assetServer) { a.limit = -1 })
+}
+
+func assertFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("downloaded %d bytes differ from the %d served", len(got), len(want))
+	}
+	for _, leftover := range []string{path + ".partial", path + ".partial" + validatorSuffix} {
+		if _, err := os.Stat(leftover); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("%s left behind", leftover)
+		}
+	}
+}
+
+func TestDownloadResumesAcrossRuns(t *testing.T) {
+	content := randomBytes(t, 64<<10)
+	a, srv := newAssetServer(t, content, `"v1"`)
+	path := filepath.Join(t.TempDir(), "sekai.tar.gz")
+
+	interruptedRun(t, a, srv.URL, path)
+	// the retry within the run already resumed once
+	st, err := os.Stat(path + ".partial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Size() != 40000 {
+		t.Fatalf("partial has %d bytes, want 40000", st.Size())
+	}
+
+	if err := Download(context.Background(), http.DefaultClient, srv.URL, path, fastRetry); err != nil {
+		t.Fatal(err)
+	}
+	h := a.lastRequest()
+	if got := h.Get("Range"); got != "bytes=40000-" {
+		t.Fatalf("Range %q", got)
+	}
+	if got := h.Get("If-Range"); got != `"v1"` {
+		t.Fatalf("If-Range %q", got)
+	}
+	assertFile(t, path, content)
+}
+
+func TestDownloadRestartsChangedFile(t *testing.T) {
+	a, srv := newAssetServer(t, randomBytes(t, 64<<10), `"v1"`)
+	path := filepath.Join(t.TempDir(), "sekai.tar.gz")
+	interruptedRun(t, a, srv.URL, path)
+
+	// a new upload under the same url must not be spliced onto the old partial
+	changed := randomBytes(t, 50<<10)
+	a.set(func(a *assetServer) { a.content, a.etag = changed, `"v2"` })
+	if err := Download(context.Background(), http.DefaultClient, srv.URL, path, fastRetry); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.lastRequest().Get("If-Range"); got != `"v1"` {
+		t.Fatalf("If-Range %q", got)
+	}
+	assertFile(t, path, changed)
+}
+
+func TestDownloadWithoutValidatorStartsOver(t *testing.T) {
+	content := randomBytes(t, 64<<10)
+	a, srv := newAssetServer(t, content, "")
+	path := filepath.Join(t.TempDir(), "sekai.tar.gz")
+	interruptedRun(t, a, srv.URL, path)
+
+	if err := Download(context.Background(), http.DefaultClient, srv.URL, path, fastRetry); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.lastRequest().Get("Range"); got != "" {
+		t.Fatalf("resumed a partial that can not be validated: Range %q", got)
+	}
+	assertFile(t, path, content)
+}
+
+func TestDownloadRetries(t *testing.T) {
+	content := randomBytes(t, 1<<10)
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/missing":
+			http.NotFound(w, r)
+		case n <= 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write(content)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+
+	opts := Options{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	path := filepath.Join(dir, "asset")
+	if err := Download(context.Background(), http.DefaultClient, srv.URL+"/asset", path, opts); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path, content)
+
+	mu.Lock()
+	calls = 0
+	mu.Unlock()
+	if err := Download(context.Background(), http.DefaultClient, srv.URL+"/missing", filepath.Join(dir, "missing"), opts); err == nil {
+		t.Fatal("404 succeeded")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Fatalf("404 was requested %d times", calls)
+	}
+}
@@ -0,0 +1,325 @@
+package gitres
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAPIBaseURL is the public GitHub REST API root.
+const DefaultAPIBaseURL = "https://api.github.com"
+
+// TokenEnvVars are checked in order for a GitHub token.
+var TokenEnvVars = []string{"SEKAI_MANAGER_GITHUB_TOKEN", "GITHUB_TOKEN", "GH_TOKEN"}
+
+// maxMetadataSize bounds a single API response.
+const maxMetadataSize = 32 << 20
+
+// Client queries the GitHub (Enterprise) REST API for release metadata.
+type Client struct {
+	HTTP *http.Client
+	// BaseURL is the API root, e.g. https://github.example.com/api/v3 for Enterprise.
+	BaseURL string
+	// Token is sent as bearer token when set; it raises the rate limit and opens private repos.
+	Token string
+	// CacheDir keeps responses with their ETag for conditional requests; empty disables it.
+	CacheDir string
+	// MaxRateLimitWait is the longest we sleep for a rate limit reset; beyond that we fail.
+	MaxRateLimitWait time.Duration
+	Verbose          bool
+
+	mu   sync.Mutex
+	rate RateLimit
+}
+
+// RateLimit is what the X-RateLimit-* headers of the last response said.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Used      int
+	Reset     time.Time
+	Resource  string
+}
+
+// RateLimitError is returned when the API refuses requests until Reset.
+type RateLimitError struct {
+	RateLimit
+	// RetryAfter is set for secondary rate limits.
+	RetryAfter    time.Duration
+	Authenticated bool
+}
+
+func (e *RateLimitError) Error() string {
+	msg := "GitHub API rate limit exceeded"
+	switch {
+	case e.RetryAfter > 0:
+		msg += fmt.Sprintf(", retry after %s", e.RetryAfter.Round(time.Second))
+	case !e.Reset.IsZero():
+		msg += fmt.Sprintf(" (%d requests/hour), resets at %s", e.Limit, e.Reset.Local().Format(time.TimeOnly))
+	}
+	if !e.Authenticated {
+		msg += fmt.Sprintf("; set %s or github.token in cfg.toml for a higher limit", TokenEnvVars[1])
+	}
+	return msg
+}
+
+// NewClient returns a client for the public API with the token from the environment.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{HTTP: httpClient, BaseURL: DefaultAPIBaseURL, Token: TokenFromEnv()}
+}
+
+// TokenFromEnv returns the first non-empty variable of TokenEnvVars.
+func TokenFromEnv() string {
+	for _, name := range TokenEnvVars {
+		if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// RateLimit returns the rate limit state seen on the last response.
+func (c *Client) RateLimit() RateLimit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rate
+}
+
+func (c *Client) url(format string, args ...any) string {
+	base := strings.TrimRight(c.BaseURL, "/")
+	if base == "" {
+		base = DefaultAPIBaseURL
+	}
+	return base + fmt.Sprintf(format, args...)
+}
+
+// cacheEntry is a stored response; Link is kept for pagination.
+type cacheEntry struct {
+	URL  string          `json:"url"`
+	ETag string          `json:"etag"`
+	Link string          `json:"link,omitempty"`
+	Body json.RawMessage `json:"body"`
+}
+
+// getJSON GETs api into v. With a cache it sends If-None-Match and reuses the stored
+// body on 304. When rate limited it waits up to MaxRateLimitWait for the reset, and
+// otherwise falls back to a cached body (with a warning) or fails with *RateLimitError.
+// The returned Link header is the response's or the cached one.
+func (c *Client) getJSON(ctx context.Context, api string, v any) (link string, err error) {
+	cached := c.loadCache(api)
+	for waited := false; ; waited = true {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, api, nil)
+		if err != nil {
+			return "", err
+		}
+		setUA(req)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+		if cached != nil {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return "", err
+		}
+		body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+		resp.Body.Close()
+		rate := parseRateLimit(resp.Header)
+		c.mu.Lock()
+		c.rate = rate
+		c.mu.Unlock()
+
+		switch {
+		case resp.StatusCode == http.StatusNotModified && cached != nil:
+			if c.Verbose {
+				fmt.Println("Using cached", api)
+			}
+			return cached.Link, json.Unmarshal(cached.Body, v)
+		case resp.StatusCode == http.StatusOK:
+			if readErr != nil {
+				return "", readErr
+			}
+			if err := json.Unmarshal(body, v); err != nil {
+				return "", fmt.Errorf("decode %s: %w", api, err)
+			}
+			c.storeCache(cacheEntry{URL: api, ETag: resp.Header.Get("ETag"), Link: resp.Header.Get("Link"), Body: body})
+			if c.Verbose && rate.Limit > 0 && rate.Remaining < rate.Limit/10 {
+				fmt.Printf("WARNING: %d of %d GitHub API requests left until %s\n", rate.Remaining, rate.Limit, rate.Reset.Local().Format(time.TimeOnly))
+			}
+			return resp.Header.Get("Link"), nil
+		case resp.StatusCode == http.StatusUnauthorized:
+			return "", fmt.Errorf("GitHub API rejected the token (401) for %s", api)
+		}
+
+		rlErr := rateLimited(resp, rate, c.Token != "")
+		if rlErr == nil {
+			return "", fmt.Errorf("GitHub API returned %d for %s", resp.StatusCode, api)
+		}
+		wait := rlErr.RetryAfter
+		if wait == 0 {
+			wait = time.Until(rlErr.Reset) + time.Second
+		}
+		if !waited && wait > 0 && wait <= c.MaxRateLimitWait {
+			fmt.Printf("GitHub API rate limited, waiting %s\n", wait.Round(time.Second))
+			if err := sleepCtx(ctx, wait); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if cached != nil {
+			fmt.Printf("WARNING: %v; using cached response of %s\n", rlErr, api)
+			return cached.Link, json.Unmarshal(cached.Body, v)
+		}
+		return "", rlErr
+	}
+}
+
+// rateLimited tells a rate limit response apart from other 403s.
+func rateLimited(resp *http.Response, rate RateLimit, authenticated bool) *RateLimitError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	e := &RateLimitError{RateLimit: rate, Authenticated: authenticated}
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if secs, err := strconv.Atoi(s); err == nil && secs >= 0 {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	if resp.StatusCode == http.StatusForbidden && e.RetryAfter == 0 && (rate.Limit == 0 || rate.Remaining > 0) {
+		return nil
+	}
+	return e
+}
+
+func parseRateLimit(h http.Header) RateLimit {
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(h.Get(key))
+		return n
+	}
+	r := RateLimit{
+		Limit:     atoi("X-RateLimit-Limit"),
+		Remaining: atoi("X-RateLimit-Remaining"),
+		Used:      atoi("X-RateLimit-Used"),
+		Resource:  h.Get("X-RateLimit-Resource"),
+	}
+	if reset := atoi("X-RateLimit-Reset"); reset > 0 {
+		r.Reset = time.Unix(int64(reset), 0)
+	}
+	return r
+}
+
+func (c *Client) cachePath(api string) string {
+	sum := sha256.Sum256([]byte(api))
+	return filepath.Join(c.CacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Client) loadCache(api string) *cacheEntry {
+	if c.CacheDir == "" {
+		return nil
+	}
+	b, err := os.ReadFile(c.cachePath(api))
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(b, &e); err != nil || e.URL != api || e.ETag == "" {
+		return nil
+	}
+	return &e
+}
+
+// storeCache is best effort, a failing cache only costs requests. Entries are
+// private to the user as responses of private repos land here too.
+func (c *Client) storeCache(e cacheEntry) {
+	if c.CacheDir == "" || e.ETag == "" {
+		return
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.CacheDir, 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.CacheDir, ".partial-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), c.cachePath(e.URL))
+}
+
+// AssetClient returns an HTTP client for the asset URLs of GetReleaseAssets. With a
+// token it authenticates requests to the API host, so assets of private repos can be
+// downloaded; the redirect to the storage host is followed without the token.
+func (c *Client) AssetClient(base *http.Client) *http.Client {
+	if base == nil {
+		base = http.DefaultClient
+	}
+	if c.Token == "" {
+		return base
+	}
+	u, err := url.Parse(c.url(""))
+	if err != nil {
+		return base
+	}
+	rt := base.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	out := *base
+	out.Transport = &assetTransport{base: rt, host: u.Host, token: c.Token}
+	return &out
+}
+
+type assetTransport struct {
+	base  http.RoundTripper
+	host  string
+	token string
+}
+
+func (t *assetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	// the asset endpoint returns metadata unless the content is asked for
+	req.Header.Set("Accept", "application/octet-stream")
+	return t.base.RoundTrip(req)
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,270 @@
+package gitres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testTag = `{"tag_name":"v0.4.1","assets":[]}`
+
+func testClient(srv *httptest.Server) *Client {
+	return &Client{HTTP: srv.Client(), BaseURL: srv.URL}
+}
+
+func getTag(c *Client) (release, error) {
+	var rel release
+	_, err := c.getJSON(context.Background(), c.url("/repos/o/r/releases/tags/v0.4.1"), &rel)
+	return rel, err
+}
+
+func TestGetJSONBaseURL(t *testing.T) {
+	var gotPath, gotAuth, gotAccept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth, gotAccept = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("Accept")
+		fmt.Fprint(w, testTag)
+	}))
+	defer srv.Close()
+
+	// an Enterprise root with a path prefix and a trailing slash
+	c := &Client{HTTP: srv.Client(), BaseURL: srv.URL + "/api/v3/", Token: "secret"}
+	rel, err := getTag(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.TagName != "v0.4.1" {
+		t.Errorf("tag %q", rel.TagName)
+	}
+	if gotPath != "/api/v3/repos/o/r/releases/tags/v0.4.1" {
+		t.Errorf("requested %s", gotPath)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Authorization %q", gotAuth)
+	}
+	if gotAccept != "application/vnd.github+json" {
+		t.Errorf("Accept %q", gotAccept)
+	}
+}
+
+func TestGetJSONNoTokenHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+		}
+		fmt.Fprint(w, testTag)
+	}))
+	defer srv.Close()
+
+	if _, err := getTag(testClient(srv)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetJSONETag(t *testing.T) {
+	var hits, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, testTag)
+	}))
+	defer srv.Close()
+
+	c := testClient(srv)
+	c.CacheDir = filepath.Join(t.TempDir(), "github")
+	for i := 0; i < 2; i++ {
+		rel, err := getTag(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rel.TagName != "v0.4.1" {
+			t.Fatalf("request %d: tag %q", i, rel.TagName)
+		}
+	}
+	if hits.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("%d requests with %d not modified, want 2 with 1", hits.Load(), notModified.Load())
+	}
+
+	entries, err := os.ReadDir(c.CacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("cache holds %d files, want 1", len(entries))
+	}
+	st, err := os.Stat(filepath.Join(c.CacheDir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mode().Perm() != 0o600 {
+		t.Errorf("cache file mode %o, want 600", st.Mode().Perm())
+	}
+}
+
+func TestGetJSONRateLimitWait(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, testTag)
+	}))
+	defer srv.Close()
+
+	c := testClient(srv)
+	c.MaxRateLimitWait = 5 * time.Second
+	start := time.Now()
+	if _, err := getTag(c); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("%d requests, want 2", hits.Load())
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %s, want the Retry-After of 1s", waited)
+	}
+}
+
+// rateLimitedStub answers like the primary rate limit of the API, reset in an hour.
+func rateLimitedStub(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetJSONRateLimitError(t *testing.T) {
+	var hits atomic.Int32
+	c := testClient(rateLimitedStub(t, &hits))
+	c.MaxRateLimitWait = time.Minute
+
+	_, err := getTag(c)
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("want *RateLimitError, got %v", err)
+	}
+	if rlErr.Limit != 60 || rlErr.Remaining != 0 || rlErr.Authenticated {
+		t.Errorf("unexpected rate limit %+v", rlErr)
+	}
+	if !strings.Contains(err.Error(), TokenEnvVars[1]) {
+		t.Errorf("error should suggest a token: %v", err)
+	}
+	// the reset is beyond MaxRateLimitWait, so there is no retry
+	if hits.Load() != 1 {
+		t.Errorf("%d requests, want 1", hits.Load())
+	}
+}
+
+func TestGetJSONRateLimitCacheFallback(t *testing.T) {
+	cacheDir := t.TempDir()
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, testTag)
+	}))
+	defer ok.Close()
+	c := testClient(ok)
+	c.CacheDir = cacheDir
+	if _, err := getTag(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same URL under the limit; the cache is keyed by URL, so fake the base
+	var hits atomic.Int32
+	limited := rateLimitedStub(t, &hits)
+	c = &Client{HTTP: &http.Client{Transport: redirectTo(limited.URL)}, BaseURL: ok.URL, CacheDir: cacheDir}
+	rel, err := getTag(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.TagName != "v0.4.1" || hits.Load() != 1 {
+		t.Errorf("got tag %q after %d requests, want the cached v0.4.1 after 1", rel.TagName, hits.Load())
+	}
+}
+
+// redirectTo sends every request to the server at the given URL instead.
+type redirectTo string
+
+func (r redirectTo) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Host = strings.TrimPrefix(string(r), "http://")
+	req.Host = req.URL.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestAssetDownloadToken(t *testing.T) {
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("token leaked to the storage host: %q", auth)
+		}
+		fmt.Fprint(w, "asset body")
+	}))
+	defer storage.Close()
+
+	var api *httptest.Server
+	api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/releases/tags/v0.4.1":
+			fmt.Fprintf(w, `{"tag_name":"v0.4.1","assets":[{"name":"sekai-linux-amd64.deb","url":%q,"browser_download_url":%q}]}`,
+				api.URL+"/repos/o/r/releases/assets/7", storage.URL+"/browser/sekai-linux-amd64.deb")
+		case "/repos/o/r/releases/assets/7":
+			if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Accept") != "application/octet-stream" {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			http.Redirect(w, r, storage.URL+"/signed/7", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	for _, token := range []string{"", "secret"} {
+		c := &Client{HTTP: api.Client(), BaseURL: api.URL, Token: token}
+		assets, err := c.GetReleaseAssets(context.Background(), "o", "r", "v0.4.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(assets) != 1 {
+			t.Fatalf("got %d assets", len(assets))
+		}
+		wantPrefix := storage.URL
+		if token != "" {
+			wantPrefix = api.URL
+		}
+		if !strings.HasPrefix(assets[0].URL, wantPrefix) {
+			t.Errorf("token %q: asset URL %s, want one on %s", token, assets[0].URL, wantPrefix)
+		}
+
+		resp, err := c.AssetClient(nil).Get(assets[0].URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "asset body" {
+			t.Errorf("token %q: got %d %q", token, resp.StatusCode, body)
+		}
+	}
+}
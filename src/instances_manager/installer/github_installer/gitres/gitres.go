@@ -2,15 +2,17 @@ package gitres
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 )
 
 type release struct {
-	Assets []struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Assets     []struct {
 		Name               string `json:"name"`
+		URL                string `json:"url"`
 		BrowserDownloadURL string `json:"browser_download_url"`
 	} `json:"assets"`
 }
@@ -53,34 +55,45 @@ func URLExists(ctx context.Context, client *http.Client, url string) (bool, int,
 	}
 }
 
-// FindAssetURL queries /repos/{owner}/{repo}/releases/tags/{tag} (no token)
-// and returns the BrowserDownloadURL of the first asset whose name contains nameContains.
-func FindAssetURL(ctx context.Context, client *http.Client, owner, repo, tag, nameContains string, verbose bool) (string, error) {
-	api := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/%s", owner, repo, tag)
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, api, nil)
-	setUA(req)
-	req.Header.Set("Accept", "application/vnd.github+json")
+// Asset is a downloadable file attached to a release.
+type Asset struct {
+	Name string
+	URL  string
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
+// GetReleaseAssets queries /repos/{owner}/{repo}/releases/tags/{tag}
+// and returns the assets of the release. With a token the asset URLs are API
+// endpoints, which unlike browser URLs serve private repos; download them with
+// the client of AssetClient.
+func (c *Client) GetReleaseAssets(ctx context.Context, owner, repo, tag string) ([]Asset, error) {
+	var rel release
+	if _, err := c.getJSON(ctx, c.url("/repos/%s/%s/releases/tags/%s", owner, repo, tag), &rel); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("GitHub API returned %d for %s", resp.StatusCode, api)
+	assets := make([]Asset, 0, len(rel.Assets))
+	for _, a := range rel.Assets {
+		u := a.BrowserDownloadURL
+		if c.Token != "" && a.URL != "" {
+			u = a.URL
+		}
+		assets = append(assets, Asset{Name: a.Name, URL: u})
 	}
+	return assets, nil
+}
 
-	var rel release
-	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+// FindAssetURL queries /repos/{owner}/{repo}/releases/tags/{tag}
+// and returns the BrowserDownloadURL of the asset SelectAsset picks under rules.
+func (c *Client) FindAssetURL(ctx context.Context, owner, repo, tag string, rules AssetRules) (string, error) {
+	assets, err := c.GetReleaseAssets(ctx, owner, repo, tag)
+	if err != nil {
 		return "", err
 	}
-	for _, a := range rel.Assets {
-		if strings.Contains(a.Name, nameContains) {
-			if verbose {
-				fmt.Println("Selected asset:", a.Name)
-			}
-			return a.BrowserDownloadURL, nil
-		}
+	a, _, err := SelectAsset(assets, rules)
+	if err != nil {
+		return "", fmt.Errorf("tag %s: %w", tag, err)
+	}
+	if c.Verbose {
+		fmt.Println("Selected asset:", a.Name)
 	}
-	return "", fmt.Errorf("no asset matched %q in tag %s", nameContains, tag)
+	return a.URL, nil
 }
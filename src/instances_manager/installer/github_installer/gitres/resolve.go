@@ -0,0 +1,146 @@
+package gitres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// LATEST resolves to the newest stable release.
+	LATEST = "latest"
+	// LATEST_RC resolves to the newest release including prereleases.
+	LATEST_RC = "latest-rc"
+
+	releasesPerPage = 100
+	// maxReleasePages bounds pagination in case the API keeps returning full pages.
+	maxReleasePages = 50
+)
+
+// Release is a GitHub release as seen by the resolver.
+type Release struct {
+	Tag        string
+	Draft      bool
+	Prerelease bool
+}
+
+// ResolveOptions controls ResolveVersion.
+type ResolveOptions struct {
+	// IncludePrerelease lets constraints and "latest" match prereleases.
+	IncludePrerelease bool
+	Verbose           bool
+}
+
+// ListReleases returns all releases of owner/repo, following pagination. It fails
+// rather than return a partial list when there are more than maxReleasePages pages.
+func (c *Client) ListReleases(ctx context.Context, owner, repo string) ([]Release, error) {
+	var out []Release
+	for page := 1; page <= maxReleasePages; page++ {
+		var rels []release
+		link, err := c.getJSON(ctx, c.url("/repos/%s/%s/releases?per_page=%d&page=%d", owner, repo, releasesPerPage, page), &rels)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range rels {
+			out = append(out, Release{Tag: r.TagName, Draft: r.Draft, Prerelease: r.Prerelease})
+		}
+		if len(rels) < releasesPerPage {
+			return out, nil
+		}
+		if link != "" && !strings.Contains(link, `rel="next"`) {
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("%s/%s has more than %d pages of releases, use an exact version", owner, repo, maxReleasePages)
+}
+
+// IsExactVersion reports whether spec names a single tag, so no listing is needed.
+func IsExactVersion(spec string) bool {
+	_, err := ParseVersion(spec)
+	return err == nil && leadingOp(strings.TrimSpace(spec)) == ""
+}
+
+// ValidateVersionSpec checks that spec is an exact version, latest, latest-rc or a constraint.
+func ValidateVersionSpec(spec string) error {
+	switch strings.TrimSpace(spec) {
+	case "":
+		return errors.New("version is empty")
+	case LATEST, LATEST_RC:
+		return nil
+	}
+	_, err := ParseConstraint(spec)
+	return err
+}
+
+// ResolveVersion picks the release tag matching spec: an exact tag, "latest", "latest-rc"
+// or a constraint such as "~0.4.0" or ">=0.3.45 <0.5". Drafts are always skipped,
+// prereleases unless requested. Exact tags are returned without querying the API.
+func (c *Client) ResolveVersion(ctx context.Context, owner, repo, spec string, opts ResolveOptions) (string, error) {
+	spec = strings.TrimSpace(spec)
+	if err := ValidateVersionSpec(spec); err != nil {
+		return "", err
+	}
+	if IsExactVersion(spec) {
+		return spec, nil
+	}
+
+	includePre := opts.IncludePrerelease
+	var constraint *Constraint
+	switch spec {
+	case LATEST:
+	case LATEST_RC:
+		includePre = true
+	default:
+		parsed, err := ParseConstraint(spec)
+		if err != nil {
+			return "", err
+		}
+		constraint = &parsed
+	}
+
+	releases, err := c.ListReleases(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	tag, err := pickRelease(releases, constraint, includePre)
+	if err != nil {
+		return "", fmt.Errorf("%s/%s %q: %w", owner, repo, spec, err)
+	}
+	if opts.Verbose {
+		fmt.Printf("Resolved %q to %s\n", spec, tag)
+	}
+	return tag, nil
+}
+
+func pickRelease(releases []Release, c *Constraint, includePre bool) (string, error) {
+	var (
+		bestTag string
+		best    Version
+		found   bool
+	)
+	for _, r := range releases {
+		if r.Draft {
+			continue
+		}
+		v, err := ParseVersion(r.Tag)
+		if err != nil {
+			// tags that are not versions can't be ordered
+			continue
+		}
+		if (r.Prerelease || v.IsPrerelease()) && !includePre {
+			continue
+		}
+		if c != nil && !c.Check(v) {
+			continue
+		}
+		if !found || v.Compare(best) > 0 {
+			bestTag, best, found = r.Tag, v, true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("no release matches among %d releases", len(releases))
+	}
+	return bestTag, nil
+}
@@ -0,0 +1,173 @@
+package gitres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// releasesStub serves rels on /repos/o/r/releases the way the GitHub API pages them.
+// With endless set every page is full and links to a next one.
+func releasesStub(t *testing.T, rels []release, endless bool) (*Client, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path != "/repos/o/r/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if perPage <= 0 || page <= 0 {
+			http.Error(w, "bad paging", http.StatusBadRequest)
+			return
+		}
+
+		var out []release
+		switch {
+		case endless:
+			for i := 0; i < perPage; i++ {
+				out = append(out, release{TagName: fmt.Sprintf("v0.%d.%d", page, i)})
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, r.URL.Path, page+1))
+		case (page-1)*perPage < len(rels):
+			out = rels[(page-1)*perPage : min(page*perPage, len(rels))]
+			if page*perPage < len(rels) {
+				w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, r.URL.Path, page+1))
+			}
+		}
+		if out == nil {
+			out = []release{}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	t.Cleanup(srv.Close)
+	return &Client{HTTP: srv.Client(), BaseURL: srv.URL}, &hits
+}
+
+func TestListReleasesPaging(t *testing.T) {
+	var rels []release
+	for i := 0; i < 2*releasesPerPage+5; i++ {
+		rels = append(rels, release{TagName: fmt.Sprintf("v0.3.%d", i)})
+	}
+	c, hits := releasesStub(t, rels, false)
+
+	got, err := c.ListReleases(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(rels) {
+		t.Fatalf("got %d releases, want %d", len(got), len(rels))
+	}
+	if got[len(got)-1].Tag != rels[len(rels)-1].TagName {
+		t.Errorf("last release %s, want %s", got[len(got)-1].Tag, rels[len(rels)-1].TagName)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("%d requests, want 3", hits.Load())
+	}
+}
+
+func TestListReleasesFullLastPage(t *testing.T) {
+	var rels []release
+	for i := 0; i < releasesPerPage; i++ {
+		rels = append(rels, release{TagName: fmt.Sprintf("v0.3.%d", i)})
+	}
+	c, hits := releasesStub(t, rels, false)
+
+	got, err := c.ListReleases(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without a Link header a full page can only be told apart from the last one by asking
+	if len(got) != releasesPerPage || hits.Load() != 2 {
+		t.Errorf("got %d releases in %d requests, want %d in 2", len(got), hits.Load(), releasesPerPage)
+	}
+}
+
+func TestListReleasesPageCap(t *testing.T) {
+	c, hits := releasesStub(t, nil, true)
+
+	_, err := c.ListReleases(context.Background(), "o", "r")
+	if err == nil || !strings.Contains(err.Error(), "pages of releases") {
+		t.Fatalf("want page cap error, got %v", err)
+	}
+	if hits.Load() != maxReleasePages {
+		t.Errorf("%d requests, want %d", hits.Load(), maxReleasePages)
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	rels := []release{
+		{TagName: "v0.5.0-rc.1", Prerelease: true},
+		{TagName: "v0.4.3", Draft: true},
+		{TagName: "v0.4.2"},
+		{TagName: "v0.4.2-rc.3"},
+		{TagName: "v0.4.1"},
+		{TagName: "nightly"},
+		{TagName: "v0.3.45"},
+	}
+	c, _ := releasesStub(t, rels, false)
+
+	tests := []struct {
+		spec    string
+		opts    ResolveOptions
+		want    string
+		wantErr bool
+	}{
+		{spec: LATEST, want: "v0.4.2"},
+		{spec: LATEST_RC, want: "v0.5.0-rc.1"},
+		{spec: LATEST, opts: ResolveOptions{IncludePrerelease: true}, want: "v0.5.0-rc.1"},
+		{spec: "~0.4.0", want: "v0.4.2"},
+		{spec: ">=0.3.45 <0.4.2", want: "v0.4.1"},
+		{spec: "<=0.4", want: "v0.4.2"},
+		{spec: ">0.4", wantErr: true},
+		// 0.5.0-rc.1 precedes 0.5.0, the first version past 0.4
+		{spec: ">0.4", opts: ResolveOptions{IncludePrerelease: true}, wantErr: true},
+		{spec: ">0.4.2", opts: ResolveOptions{IncludePrerelease: true}, want: "v0.5.0-rc.1"},
+		{spec: "=0.4.3", wantErr: true},
+		{spec: "^0.3", want: "v0.3.45"},
+		{spec: "~0.6", wantErr: true},
+		{spec: "~0.4.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := c.ResolveVersion(context.Background(), "o", "r", tt.spec, tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResolveVersion(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveVersion(%q) = %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestResolveVersionNoMatch(t *testing.T) {
+	c, _ := releasesStub(t, []release{{TagName: "v0.4.1"}, {TagName: "v0.4.2", Draft: true}}, false)
+
+	_, err := c.ResolveVersion(context.Background(), "o", "r", ">=0.4.2", ResolveOptions{})
+	if err == nil || !strings.Contains(err.Error(), "no release matches among 2 releases") {
+		t.Fatalf("want no matching release error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `o/r ">=0.4.2"`) {
+		t.Errorf("error should name the repo and spec: %v", err)
+	}
+}
+
+func TestResolveExactVersionSkipsAPI(t *testing.T) {
+	c, hits := releasesStub(t, nil, false)
+
+	got, err := c.ResolveVersion(context.Background(), "o", "r", "v0.4.1", ResolveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "v0.4.1" || hits.Load() != 0 {
+		t.Errorf("got %q after %d requests, want v0.4.1 without requests", got, hits.Load())
+	}
+}
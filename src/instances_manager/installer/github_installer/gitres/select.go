@@ -0,0 +1,252 @@
+package gitres
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Asset formats known to the selector. FORMAT_BINARY is a bare executable.
+const (
+	FORMAT_DEB     string = "deb"
+	FORMAT_TAR_GZ  string = "tar.gz"
+	FORMAT_TAR_ZST string = "tar.zst"
+	FORMAT_TAR_XZ  string = "tar.xz"
+	FORMAT_ZIP     string = "zip"
+	FORMAT_BINARY  string = "binary"
+)
+
+// DefaultFormats is the preference order used when AssetRules.Formats is empty.
+var DefaultFormats = []string{FORMAT_DEB, FORMAT_TAR_GZ, FORMAT_TAR_ZST, FORMAT_TAR_XZ, FORMAT_ZIP, FORMAT_BINARY}
+
+// formatSuffixes maps name suffixes to formats; longer suffixes are tried first.
+var formatSuffixes = []struct{ suffix, format string }{
+	{".tar.gz", FORMAT_TAR_GZ}, {".tgz", FORMAT_TAR_GZ},
+	{".tar.zst", FORMAT_TAR_ZST}, {".tar.zstd", FORMAT_TAR_ZST},
+	{".tar.xz", FORMAT_TAR_XZ}, {".txz", FORMAT_TAR_XZ},
+	{".deb", FORMAT_DEB}, {".zip", FORMAT_ZIP}, {".exe", FORMAT_BINARY},
+}
+
+// auxiliarySuffixes mark assets that are never the package itself.
+var auxiliarySuffixes = []string{".sha256", ".sha512", ".sig", ".asc", ".pem", ".ed25519", ".txt", ".json", ".sbom", ".rpm", ".apk", ".dmg", ".msi"}
+
+// Built-in names of each GOOS and GOARCH as they appear in asset names.
+var (
+	osAliases = map[string][]string{
+		"linux":   {"linux"},
+		"darwin":  {"darwin", "macos", "osx", "apple"},
+		"windows": {"windows", "win", "win64", "win32"},
+		"freebsd": {"freebsd"},
+	}
+	archAliases = map[string][]string{
+		"amd64": {"amd64", "x86_64", "x64", "x86-64"},
+		"arm64": {"arm64", "aarch64", "armv8"},
+		"386":   {"386", "i386", "i686", "x86"},
+		"arm":   {"arm", "armv7", "armv7l", "armhf", "armv6"},
+	}
+)
+
+// AssetRules control which release asset is installed. Zero values fall back to
+// runtime.GOOS, runtime.GOARCH and DefaultFormats.
+type AssetRules struct {
+	OS   string
+	Arch string
+	// Aliases add names for an OS or arch, e.g. {"amd64": ["x86-64-v3"]}.
+	Aliases map[string][]string
+	// Formats are acceptable formats, most preferred first.
+	Formats []string
+	// Exclude are path.Match patterns of asset names that are never picked.
+	Exclude []string
+}
+
+// Candidate is an asset the selector looked at and why it was (not) picked.
+type Candidate struct {
+	Name   string
+	OS     string
+	Arch   string
+	Format string
+	// Reason is empty for assets that matched.
+	Reason string
+}
+
+// NoAssetError lists what was considered when no asset matched.
+type NoAssetError struct {
+	OS, Arch   string
+	Candidates []Candidate
+}
+
+func (e *NoAssetError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "no release asset for %s/%s", e.OS, e.Arch)
+	if len(e.Candidates) == 0 {
+		b.WriteString(", the release has no assets")
+	}
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&b, "\n  %s: %s", c.Name, c.Reason)
+	}
+	return b.String()
+}
+
+// Validate checks formats and exclude patterns.
+func (r AssetRules) Validate() error {
+	for _, f := range r.Formats {
+		if !knownFormat(f) {
+			return fmt.Errorf("unknown asset format %q (known: %v)", f, DefaultFormats)
+		}
+	}
+	for _, p := range r.Exclude {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("exclude pattern %q: %w", p, err)
+		}
+	}
+	for name, aliases := range r.Aliases {
+		if len(aliases) == 0 {
+			return fmt.Errorf("alias list of %q is empty", name)
+		}
+	}
+	return nil
+}
+
+func (r AssetRules) withDefaults() AssetRules {
+	if r.OS == "" {
+		r.OS = runtime.GOOS
+	}
+	if r.Arch == "" {
+		r.Arch = runtime.GOARCH
+	}
+	if len(r.Formats) == 0 {
+		r.Formats = DefaultFormats
+	}
+	return r
+}
+
+// SelectAsset picks the asset for the rules' OS and arch in the most preferred format.
+// OS and arch are recognized by their usual spellings (amd64/x86_64, arm64/aarch64, ...)
+// as separate words of the name; a .deb without an OS is taken as linux. All assets
+// are returned as candidates with the reason they were skipped, and a *NoAssetError
+// carries them when nothing matched.
+func SelectAsset(assets []Asset, rules AssetRules) (Asset, []Candidate, error) {
+	rules = rules.withDefaults()
+	osNames := mergeAliases(osAliases, rules.Aliases, rules.OS)
+	archNames := mergeAliases(archAliases, rules.Aliases, rules.Arch)
+
+	best, bestRank := -1, len(rules.Formats)
+	candidates := make([]Candidate, 0, len(assets))
+	for i, a := range assets {
+		c := classify(a.Name, osNames, archNames)
+		rank := indexOf(rules.Formats, c.Format)
+		switch {
+		case c.Reason != "":
+		case excluded(rules.Exclude, a.Name):
+			c.Reason = "excluded by rule"
+		case c.OS != rules.OS:
+			c.Reason = fmt.Sprintf("os is %s", orUnknown(c.OS))
+		case c.Arch != rules.Arch:
+			c.Reason = fmt.Sprintf("arch is %s", orUnknown(c.Arch))
+		case rank < 0:
+			c.Reason = fmt.Sprintf("format %s not accepted", c.Format)
+		case rank == bestRank:
+			c.Reason = fmt.Sprintf("%s matched first", assets[best].Name)
+		case rank > bestRank:
+			c.Reason = fmt.Sprintf("format %s, %s preferred", c.Format, rules.Formats[bestRank])
+		default:
+			if best >= 0 {
+				candidates[best].Reason = fmt.Sprintf("format %s, %s preferred", candidates[best].Format, c.Format)
+			}
+			best, bestRank = i, rank
+		}
+		candidates = append(candidates, c)
+	}
+	if best < 0 {
+		return Asset{}, candidates, &NoAssetError{OS: rules.OS, Arch: rules.Arch, Candidates: candidates}
+	}
+	return assets[best], candidates, nil
+}
+
+// classify reads OS, arch and format from an asset name.
+func classify(name string, osNames, archNames map[string][]string) Candidate {
+	c := Candidate{Name: name}
+	lower := strings.ToLower(name)
+	for _, s := range auxiliarySuffixes {
+		if strings.HasSuffix(lower, s) {
+			c.Reason = "not a package"
+			return c
+		}
+	}
+	stem := lower
+	c.Format = FORMAT_BINARY
+	for _, fs := range formatSuffixes {
+		if strings.HasSuffix(lower, fs.suffix) {
+			c.Format, stem = fs.format, strings.TrimSuffix(lower, fs.suffix)
+			break
+		}
+	}
+	c.OS = longestMatch(stem, osNames)
+	c.Arch = longestMatch(stem, archNames)
+	if c.OS == "" && c.Format == FORMAT_DEB {
+		c.OS = "linux"
+	}
+	return c
+}
+
+// longestMatch returns the key whose alias occurs as a separate word of stem; when
+// several do, the longest alias wins so x86_64 is amd64 and not 386 ("x86").
+func longestMatch(stem string, names map[string][]string) string {
+	best, bestLen := "", 0
+	for key, aliases := range names {
+		for _, alias := range aliases {
+			if len(alias) > bestLen && wordRe(alias).MatchString(stem) {
+				best, bestLen = key, len(alias)
+			}
+		}
+	}
+	return best
+}
+
+func wordRe(alias string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[-_.])` + regexp.QuoteMeta(strings.ToLower(alias)) + `($|[-_.])`)
+}
+
+// mergeAliases adds the rule aliases to the built-in names. Aliases of names that
+// are neither built in nor the wanted one belong to the other table.
+func mergeAliases(builtin, extra map[string][]string, wanted string) map[string][]string {
+	out := make(map[string][]string, len(builtin)+1)
+	for k, v := range builtin {
+		out[k] = append(append([]string(nil), v...), extra[k]...)
+	}
+	if _, ok := out[wanted]; !ok {
+		out[wanted] = append([]string{wanted}, extra[wanted]...)
+	}
+	return out
+}
+
+func excluded(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func knownFormat(f string) bool {
+	return indexOf(DefaultFormats, f) >= 0
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,186 @@
+package gitres
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// KiraCore/sekai release assets.
+var kiraAssets = []string{
+	"sekai-darwin-amd64.deb",
+	"sekai-darwin-arm64.deb",
+	"sekai-linux-amd64.deb",
+	"sekai-linux-amd64.deb.sha256",
+	"sekai-linux-amd64.deb.sig",
+	"sekai-linux-arm64.deb",
+	"sekai-linux-arm64.deb.sha256",
+	"sekai-linux-arm64.deb.sig",
+	"sekai-env.sh",
+	"sekai-utils.sh",
+	"sekai-utils.sh.sig",
+	"source-code.tar.gz",
+	"source-code.tar.gz.sig",
+}
+
+// A goreleaser style community build.
+var communityAssets = []string{
+	"checksums.txt",
+	"checksums.txt.sig",
+	"sekaid_0.4.1_Darwin_arm64.tar.gz",
+	"sekaid_0.4.1_Darwin_x86_64.tar.gz",
+	"sekaid_0.4.1_Linux_aarch64.tar.gz",
+	"sekaid_0.4.1_Linux_x86_64.tar.gz",
+	"sekaid_0.4.1_Linux_x86_64.zip",
+	"sekaid_0.4.1_Windows_x86_64.zip",
+	"sekaid_0.4.1_amd64.deb",
+	"sekaid_0.4.1_arm64.deb",
+	"sekaid-linux-armv7",
+	"sekaid-linux-i686",
+	"sbom.json",
+}
+
+func assetsOf(names ...string) []Asset {
+	out := make([]Asset, 0, len(names))
+	for _, n := range names {
+		out = append(out, Asset{Name: n, URL: "https://example.com/" + n})
+	}
+	return out
+}
+
+func TestSelectAsset(t *testing.T) {
+	tests := []struct {
+		name   string
+		assets []string
+		rules  AssetRules
+		want   string
+	}{
+		{name: "kira linux amd64", assets: kiraAssets, rules: AssetRules{OS: "linux", Arch: "amd64"}, want: "sekai-linux-amd64.deb"},
+		{name: "kira linux arm64", assets: kiraAssets, rules: AssetRules{OS: "linux", Arch: "arm64"}, want: "sekai-linux-arm64.deb"},
+		{name: "kira darwin arm64", assets: kiraAssets, rules: AssetRules{OS: "darwin", Arch: "arm64"}, want: "sekai-darwin-arm64.deb"},
+		{name: "deb preferred over tar.gz", assets: communityAssets, rules: AssetRules{OS: "linux", Arch: "amd64"}, want: "sekaid_0.4.1_amd64.deb"},
+		{name: "deb without os is linux", assets: communityAssets, rules: AssetRules{OS: "linux", Arch: "arm64"}, want: "sekaid_0.4.1_arm64.deb"},
+		{name: "x86_64 is amd64", assets: communityAssets, rules: AssetRules{OS: "linux", Arch: "amd64", Formats: []string{FORMAT_TAR_GZ}}, want: "sekaid_0.4.1_Linux_x86_64.tar.gz"},
+		{name: "aarch64 is arm64", assets: communityAssets, rules: AssetRules{OS: "linux", Arch: "arm64", Formats: []string{FORMAT_TAR_GZ, FORMAT_DEB}}, want: "sekaid_0.4.1_Linux_aarch64.tar.gz"},
+		{name: "format order", assets: communityAssets, rules: AssetRules{OS: "linux", Arch: "amd64", Formats: []string{FORMAT_ZIP, FORMAT_TAR_GZ}}, want: "sekaid_0.4.1_Linux_x86_64.zip"},
+		{name: "darwin x86_64", assets: communityAssets, rules: AssetRules{OS: "darwin", Arch: "amd64"}, want: "sekaid_0.4.1_Darwin_x86_64.tar.gz"},
+		{name: "windows", assets: communityAssets, rules: AssetRules{OS: "windows", Arch: "amd64"}, want: "sekaid_0.4.1_Windows_x86_64.zip"},
+		{name: "bare binary armv7", assets: communityAssets, rules: AssetRules{OS: "linux", Arch: "arm"}, want: "sekaid-linux-armv7"},
+		{name: "i686 is 386", assets: communityAssets, rules: AssetRules{OS: "linux", Arch: "386"}, want: "sekaid-linux-i686"},
+		{
+			name:   "exclude",
+			assets: communityAssets,
+			rules:  AssetRules{OS: "linux", Arch: "amd64", Exclude: []string{"*.deb", "*.zip"}},
+			want:   "sekaid_0.4.1_Linux_x86_64.tar.gz",
+		},
+		{
+			name:   "extra alias",
+			assets: []string{"sekaid-linux-x86-64-v3.tar.gz", "sekaid-linux-arm64.tar.gz"},
+			rules:  AssetRules{OS: "linux", Arch: "amd64", Aliases: map[string][]string{"amd64": {"x86-64-v3"}}},
+			want:   "sekaid-linux-x86-64-v3.tar.gz",
+		},
+		{
+			name:   "custom os name",
+			assets: []string{"sekaid-linux-amd64.tar.gz", "sekaid-alpine-amd64.tar.gz"},
+			rules:  AssetRules{OS: "musl", Arch: "amd64", Aliases: map[string][]string{"musl": {"alpine"}}},
+			want:   "sekaid-alpine-amd64.tar.gz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, candidates, err := SelectAsset(assetsOf(tt.assets...), tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != tt.want {
+				t.Fatalf("selected %s, want %s", got.Name, tt.want)
+			}
+			if len(candidates) != len(tt.assets) {
+				t.Fatalf("%d candidates for %d assets", len(candidates), len(tt.assets))
+			}
+			for _, c := range candidates {
+				if (c.Reason == "") != (c.Name == tt.want) {
+					t.Errorf("candidate %s has reason %q", c.Name, c.Reason)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectAssetSkipsAuxiliaryFiles(t *testing.T) {
+	_, candidates, err := SelectAsset(assetsOf(kiraAssets...), AssetRules{OS: "linux", Arch: "amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reasons := map[string]string{}
+	for _, c := range candidates {
+		reasons[c.Name] = c.Reason
+	}
+	for _, name := range []string{"sekai-linux-amd64.deb.sha256", "sekai-linux-amd64.deb.sig", "source-code.tar.gz.sig"} {
+		if reasons[name] != "not a package" {
+			t.Errorf("%s: reason %q", name, reasons[name])
+		}
+	}
+	if r := reasons["sekai-linux-arm64.deb"]; r != "arch is arm64" {
+		t.Errorf("arm64 deb: reason %q", r)
+	}
+	if r := reasons["sekai-darwin-amd64.deb"]; r != "os is darwin" {
+		t.Errorf("darwin deb: reason %q", r)
+	}
+	if r := reasons["source-code.tar.gz"]; r != "os is unknown" {
+		t.Errorf("source code: reason %q", r)
+	}
+}
+
+func TestSelectAssetNoMatch(t *testing.T) {
+	_, candidates, err := SelectAsset(assetsOf(kiraAssets...), AssetRules{OS: "freebsd", Arch: "amd64"})
+	var noAsset *NoAssetError
+	if !errors.As(err, &noAsset) {
+		t.Fatalf("want *NoAssetError, got %v", err)
+	}
+	if noAsset.OS != "freebsd" || noAsset.Arch != "amd64" {
+		t.Errorf("error for %s/%s", noAsset.OS, noAsset.Arch)
+	}
+	if len(noAsset.Candidates) != len(kiraAssets) || len(candidates) != len(kiraAssets) {
+		t.Fatalf("%d candidates in the error, %d returned, want %d", len(noAsset.Candidates), len(candidates), len(kiraAssets))
+	}
+	msg := err.Error()
+	for _, want := range []string{"no release asset for freebsd/amd64", "sekai-linux-amd64.deb: os is linux", "sekai-linux-amd64.deb.sig: not a package"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error lacks %q:\n%s", want, msg)
+		}
+	}
+
+	_, _, err = SelectAsset(nil, AssetRules{OS: "linux", Arch: "amd64"})
+	if err == nil || !strings.Contains(err.Error(), "the release has no assets") {
+		t.Errorf("empty release: %v", err)
+	}
+}
+
+func TestSelectAssetFormatNotAccepted(t *testing.T) {
+	_, candidates, err := SelectAsset(assetsOf("sekaid-linux-amd64.deb", "sekaid-linux-amd64.tar.xz"), AssetRules{OS: "linux", Arch: "amd64", Formats: []string{FORMAT_ZIP}})
+	if err == nil {
+		t.Fatal("selected an asset of a format that is not accepted")
+	}
+	if candidates[0].Reason != "format deb not accepted" || candidates[1].Reason != "format tar.xz not accepted" {
+		t.Errorf("reasons %q, %q", candidates[0].Reason, candidates[1].Reason)
+	}
+}
+
+func TestAssetRulesValidate(t *testing.T) {
+	tests := []struct {
+		rules   AssetRules
+		wantErr bool
+	}{
+		{rules: AssetRules{}},
+		{rules: AssetRules{Formats: []string{FORMAT_TAR_GZ, FORMAT_BINARY}, Exclude: []string{"*-musl-*"}, Aliases: map[string][]string{"amd64": {"x86-64-v3"}}}},
+		{rules: AssetRules{Formats: []string{"rpm"}}, wantErr: true},
+		{rules: AssetRules{Exclude: []string{"[a-"}}, wantErr: true},
+		{rules: AssetRules{Aliases: map[string][]string{"amd64": {}}}, wantErr: true},
+	}
+	for i, tt := range tests {
+		if err := tt.rules.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%d: error = %v, wantErr %v", i, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package gitres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version. Tags like "v0.4.1" and "0.3.45-rc.2" are accepted.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 []string
+	// parts is how many of major.minor.patch were given; constraints may use "0.4".
+	parts int
+}
+
+// ParseVersion parses a full major.minor.patch version with optional prerelease and build.
+func ParseVersion(s string) (Version, error) {
+	v, err := parseVersion(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if v.parts != 3 {
+		return Version{}, fmt.Errorf("version %q: want major.minor.patch", s)
+	}
+	return v, nil
+}
+
+func parseVersion(s string) (Version, error) {
+	orig := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v Version
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if s[i+1:] == "" {
+			return Version{}, fmt.Errorf("version %q: empty prerelease", orig)
+		}
+		v.Pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	nums := strings.Split(s, ".")
+	if len(nums) == 0 || len(nums) > 3 || nums[0] == "" {
+		return Version{}, fmt.Errorf("invalid version %q", orig)
+	}
+	for i, n := range nums {
+		x, err := strconv.Atoi(n)
+		if err != nil || x < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", orig)
+		}
+		switch i {
+		case 0:
+			v.Major = x
+		case 1:
+			v.Minor = x
+		case 2:
+			v.Patch = x
+		}
+	}
+	v.parts = len(nums)
+	return v, nil
+}
+
+func (v Version) IsPrerelease() bool { return len(v.Pre) > 0 }
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 following semver precedence rules.
+func (v Version) Compare(o Version) int {
+	for _, d := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if d[0] != d[1] {
+			return sign(d[0] - d[1])
+		}
+	}
+	// a version without prerelease has higher precedence
+	switch {
+	case len(v.Pre) == 0 && len(o.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(o.Pre); i++ {
+		if c := comparePreIdent(v.Pre[i], o.Pre[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(v.Pre) - len(o.Pre))
+}
+
+func comparePreIdent(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return sign(an - bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+// Constraint is a set of alternatives ("||"), each a list of comparisons that must all hold.
+// Supported operators: = != > >= < <= ~ ^. Comparisons are separated by spaces or commas.
+type Constraint struct {
+	alternatives [][]comparison
+	raw          string
+}
+
+type comparison struct {
+	op string
+	v  Version
+	// upper bounds the range excluded by the internal "!~" operator.
+	upper Version
+}
+
+// ParseConstraint parses expressions like "~0.4.0", ">=0.3.45 <0.5" or "0.4.1 || 0.4.2".
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: s}
+	for _, alt := range strings.Split(s, "||") {
+		fields := strings.FieldsFunc(alt, func(r rune) bool { return r == ' ' || r == ',' })
+		if len(fields) == 0 {
+			return Constraint{}, fmt.Errorf("constraint %q: empty expression", s)
+		}
+		var cmps []comparison
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			op := leadingOp(f)
+			rest := f[len(op):]
+			// allow ">= 0.3.45" with a space after the operator
+			if rest == "" && i+1 < len(fields) {
+				i++
+				rest = fields[i]
+			}
+			v, err := parseVersion(rest)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("constraint %q: %w", s, err)
+			}
+			if op == "" {
+				op = "="
+			}
+			cmps = append(cmps, expand(op, v)...)
+		}
+		c.alternatives = append(c.alternatives, cmps)
+	}
+	return c, nil
+}
+
+func leadingOp(s string) string {
+	for _, op := range []string{">=", "<=", "!=", "==", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// expand rewrites ~, ^ and partial versions into plain range comparisons.
+// A partial version stands for the whole range it covers, so ">0.4" is ">=0.5.0",
+// "<=0.4" is "<0.5.0" and "!=0.4" excludes every 0.4.x.
+func expand(op string, v Version) []comparison {
+	lower := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Pre: v.Pre, parts: 3}
+	// upper is the first version past the minor, or past the major for a 1-part version
+	upper := Version{Major: v.Major, Minor: v.Minor + 1, parts: 3}
+	if v.parts == 1 {
+		upper = Version{Major: v.Major + 1, parts: 3}
+	}
+	switch op {
+	case "~":
+		return []comparison{{op: ">=", v: lower}, {op: "<", v: upper}}
+	case "^":
+		switch {
+		case v.Major > 0 || v.parts == 1:
+			upper = Version{Major: v.Major + 1, parts: 3}
+		case v.Minor > 0 || v.parts == 2:
+			upper = Version{Minor: v.Minor + 1, parts: 3}
+		default:
+			upper = Version{Patch: v.Patch + 1, parts: 3}
+		}
+		return []comparison{{op: ">=", v: lower}, {op: "<", v: upper}}
+	}
+	if v.parts == 3 {
+		if op == "==" {
+			op = "="
+		}
+		return []comparison{{op: op, v: lower}}
+	}
+
+	switch op {
+	case "=", "==":
+		// "=0.4" means any 0.4.x
+		return []comparison{{op: ">=", v: lower}, {op: "<", v: upper}}
+	case "!=":
+		return []comparison{{op: "!~", v: lower, upper: upper}}
+	case ">":
+		return []comparison{{op: ">=", v: upper}}
+	case "<=":
+		return []comparison{{op: "<", v: upper}}
+	}
+	// >= and < are bounded by the start of the range
+	return []comparison{{op: op, v: lower}}
+}
+
+// Check reports whether v satisfies the constraint.
+func (c Constraint) Check(v Version) bool {
+	for _, alt := range c.alternatives {
+		ok := true
+		for _, cmp := range alt {
+			if !cmp.check(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (cmp comparison) check(v Version) bool {
+	c := v.Compare(cmp.v)
+	switch cmp.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case "!~":
+		return c < 0 || v.Compare(cmp.upper) >= 0
+	}
+	return false
+}
+
+func (c Constraint) String() string { return c.raw }
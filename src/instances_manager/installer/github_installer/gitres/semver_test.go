@@ -0,0 +1,131 @@
+package gitres
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "v0.4.1", want: "0.4.1"},
+		{in: "0.3.45-rc.2", want: "0.3.45-rc.2"},
+		{in: "v1.2.3+build.7", want: "1.2.3"},
+		{in: "0.4", wantErr: true},
+		{in: "v0", wantErr: true},
+		{in: "0.4.1-", wantErr: true},
+		{in: "0.4.x", wantErr: true},
+		{in: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseVersion(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && v.String() != tt.want {
+			t.Errorf("ParseVersion(%q) = %s, want %s", tt.in, v, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	ordered := []string{"0.3.45", "0.4.0-rc.1", "0.4.0-rc.2", "0.4.0-rc.10", "0.4.0-rc.beta", "0.4.0", "0.4.1", "0.10.0", "1.0.0"}
+	for i := range ordered {
+		for j := range ordered {
+			a, b := mustVersion(t, ordered[i]), mustVersion(t, ordered[j])
+			if got, want := a.Compare(b), sign(i-j); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		// 3-part versions compare exactly
+		{"0.4.1", []string{"0.4.1"}, []string{"0.4.0", "0.4.2"}},
+		{"=0.4.1", []string{"0.4.1"}, []string{"0.4.0", "0.4.2"}},
+		{"==0.4.1", []string{"0.4.1"}, []string{"0.4.0", "0.4.2"}},
+		{"!=0.4.1", []string{"0.4.0", "0.4.2"}, []string{"0.4.1"}},
+		{">0.4.1", []string{"0.4.2", "0.5.0"}, []string{"0.4.0", "0.4.1"}},
+		{">=0.4.1", []string{"0.4.1", "0.4.2"}, []string{"0.4.0"}},
+		{"<0.4.1", []string{"0.4.0", "0.3.45"}, []string{"0.4.1", "0.4.2"}},
+		{"<=0.4.1", []string{"0.4.0", "0.4.1"}, []string{"0.4.2"}},
+		{"~0.4.1", []string{"0.4.1", "0.4.9"}, []string{"0.4.0", "0.5.0"}},
+		{"^0.4.1", []string{"0.4.1", "0.4.9"}, []string{"0.4.0", "0.5.0"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.2", "0.0.4"}},
+
+		// 2-part versions stand for every patch of the minor
+		{"0.4", []string{"0.4.0", "0.4.5"}, []string{"0.3.9", "0.5.0"}},
+		{"=0.4", []string{"0.4.0", "0.4.5"}, []string{"0.3.9", "0.5.0"}},
+		{"==0.4", []string{"0.4.0", "0.4.5"}, []string{"0.3.9", "0.5.0"}},
+		{"!=0.4", []string{"0.3.9", "0.5.0"}, []string{"0.4.0", "0.4.5"}},
+		{">0.4", []string{"0.5.0", "1.0.0"}, []string{"0.4.0", "0.4.1", "0.4.99"}},
+		{">=0.4", []string{"0.4.0", "0.4.5", "0.5.0"}, []string{"0.3.9"}},
+		{"<0.4", []string{"0.3.9"}, []string{"0.4.0", "0.4.5"}},
+		{"<=0.4", []string{"0.3.9", "0.4.0", "0.4.5"}, []string{"0.5.0"}},
+		{"~0.4", []string{"0.4.0", "0.4.5"}, []string{"0.3.9", "0.5.0"}},
+		{"^0.4", []string{"0.4.0", "0.4.5"}, []string{"0.3.9", "0.5.0"}},
+		{"^1.2", []string{"1.2.0", "1.9.0"}, []string{"1.1.9", "2.0.0"}},
+		{"^0.0", []string{"0.0.0", "0.0.9"}, []string{"0.1.0"}},
+
+		// 1-part versions stand for every minor of the major
+		{"1", []string{"1.0.0", "1.9.9"}, []string{"0.9.9", "2.0.0"}},
+		{"=1", []string{"1.0.0", "1.9.9"}, []string{"0.9.9", "2.0.0"}},
+		{"==1", []string{"1.0.0", "1.9.9"}, []string{"0.9.9", "2.0.0"}},
+		{"!=1", []string{"0.9.9", "2.0.0"}, []string{"1.0.0", "1.9.9"}},
+		{">1", []string{"2.0.0"}, []string{"1.0.0", "1.9.9"}},
+		{">=1", []string{"1.0.0", "2.0.0"}, []string{"0.9.9"}},
+		{"<1", []string{"0.9.9"}, []string{"1.0.0", "1.0.1"}},
+		{"<=1", []string{"0.9.9", "1.9.9"}, []string{"2.0.0"}},
+		{"~1", []string{"1.0.0", "1.9.9"}, []string{"0.9.9", "2.0.0"}},
+		{"^1", []string{"1.0.0", "1.9.9"}, []string{"0.9.9", "2.0.0"}},
+		{"^0", []string{"0.0.0", "0.9.9"}, []string{"1.0.0"}},
+
+		// combinations
+		{">=0.3.45 <0.5", []string{"0.3.45", "0.4.9"}, []string{"0.3.44", "0.5.0"}},
+		{">= 0.3.45, < 0.5", []string{"0.3.45", "0.4.9"}, []string{"0.3.44", "0.5.0"}},
+		{"0.4.1 || 0.4.3", []string{"0.4.1", "0.4.3"}, []string{"0.4.2"}},
+		{">0.4 || <0.3", []string{"0.2.9", "0.5.0"}, []string{"0.3.0", "0.4.5"}},
+		{">=0.4.0-rc.1", []string{"0.4.0-rc.2", "0.4.0"}, []string{"0.4.0-rc.0", "0.3.9"}},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", tt.constraint, err)
+		}
+		for _, s := range tt.match {
+			if !c.Check(mustVersion(t, s)) {
+				t.Errorf("%q should match %s", tt.constraint, s)
+			}
+		}
+		for _, s := range tt.noMatch {
+			if c.Check(mustVersion(t, s)) {
+				t.Errorf("%q should not match %s", tt.constraint, s)
+			}
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, s := range []string{"", "||", ">=", "~x", "0.4.1 ||", ">=0.1.2.3"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) should fail", s)
+		}
+	}
+}
+
+func mustVersion(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := ParseVersion(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
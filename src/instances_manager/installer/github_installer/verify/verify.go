@@ -0,0 +1,140 @@
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// checksumAssetNames are the checksum files looked for in a release, in order of preference.
+var checksumAssetNames = []string{"sha256sums", "sha256sums.txt", "SHA256SUMS", "SHA256SUMS.txt", "checksums.txt"}
+
+// signatureSuffixes are appended to the checksum asset name to find its detached signature.
+var signatureSuffixes = []string{".sig", ".ed25519"}
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ChecksumAssetNames returns the checksum file names for a release asset; a per-asset
+// "<asset>.sha256" file is tried before the release-wide lists.
+func ChecksumAssetNames(asset string) []string {
+	return append([]string{asset + ".sha256"}, checksumAssetNames...)
+}
+
+// SignatureAssetNames returns the possible detached signature names of a checksum file.
+func SignatureAssetNames(checksumAsset string) []string {
+	out := make([]string, 0, len(signatureSuffixes))
+	for _, s := range signatureSuffixes {
+		out = append(out, checksumAsset+s)
+	}
+	return out
+}
+
+// ParseChecksums reads sha256sum output: "<hex>  <name>" or "<hex> *<name>" per line.
+// A file holding a bare digest maps it to the empty name.
+func ParseChecksums(data []byte) (map[string]string, error) {
+	out := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		digest := strings.ToLower(fields[0])
+		if len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("line %d: not a sha256 digest", n)
+		}
+		if _, err := hex.DecodeString(digest); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		name := ""
+		if len(fields) > 1 {
+			name = strings.TrimPrefix(strings.Join(fields[1:], " "), "*")
+		}
+		out[name] = digest
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no checksums found")
+	}
+	return out, nil
+}
+
+// LookupChecksum finds the digest for asset, falling back to a bare digest.
+func LookupChecksum(sums map[string]string, asset string) (string, bool) {
+	if d, ok := sums[asset]; ok {
+		return d, true
+	}
+	d, ok := sums[""]
+	return d, ok
+}
+
+// FileSHA256 returns the hex sha256 of a file.
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ParsePublicKeys decodes base64 or hex encoded ed25519 public keys.
+func ParsePublicKeys(keys []string) ([]ed25519.PublicKey, error) {
+	out := make([]ed25519.PublicKey, 0, len(keys))
+	for i, k := range keys {
+		b, err := decodeKeyOrSig(k, ed25519.PublicKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		out = append(out, ed25519.PublicKey(b))
+	}
+	return out, nil
+}
+
+// Signature checks a detached ed25519 signature over message against any of the pinned keys.
+// sig may be raw, base64 or hex encoded.
+func Signature(message, sig []byte, keys []ed25519.PublicKey) error {
+	if len(keys) == 0 {
+		return errors.New("no trusted keys configured")
+	}
+	raw := sig
+	if len(raw) != ed25519.SignatureSize {
+		b, err := decodeKeyOrSig(string(sig), ed25519.SignatureSize)
+		if err != nil {
+			return fmt.Errorf("signature: %w", err)
+		}
+		raw = b
+	}
+	for _, k := range keys {
+		if ed25519.Verify(k, message, raw) {
+			return nil
+		}
+	}
+	return errors.New("signature does not match any trusted key")
+}
+
+func decodeKeyOrSig(s string, size int) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && len(b) == size {
+		return b, nil
+	}
+	if b, err := hex.DecodeString(s); err == nil && len(b) == size {
+		return b, nil
+	}
+	return nil, fmt.Errorf("expected %d bytes in base64 or hex", size)
+}
@@ -0,0 +1,145 @@
+package verify
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	digestA = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	digestB = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+)
+
+func TestChecksumAssetNames(t *testing.T) {
+	want := []string{"sekai-linux-amd64.deb.sha256", "sha256sums", "sha256sums.txt", "SHA256SUMS", "SHA256SUMS.txt", "checksums.txt"}
+	if got := ChecksumAssetNames("sekai-linux-amd64.deb"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := SignatureAssetNames("sha256sums"); !reflect.DeepEqual(got, []string{"sha256sums.sig", "sha256sums.ed25519"}) {
+		t.Errorf("signature names %v", got)
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "sha256sum output",
+			data: "# release v0.4.1\n" + digestA + "  sekai-linux-amd64.deb\n\n" + strings.ToUpper(digestB) + " *sekai-darwin-arm64.tar.gz\n",
+			want: map[string]string{"sekai-linux-amd64.deb": digestA, "sekai-darwin-arm64.tar.gz": digestB},
+		},
+		{name: "bare digest", data: digestA + "\n", want: map[string]string{"": digestA}},
+		{name: "short digest", data: digestA[:40] + "  sekaid\n", wantErr: true},
+		{name: "not hex", data: strings.Repeat("z", 64) + "  sekaid\n", wantErr: true},
+		{name: "empty", data: "# nothing\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseChecksums([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLookupChecksum(t *testing.T) {
+	sums := map[string]string{"sekai-linux-amd64.deb": digestA}
+	if d, ok := LookupChecksum(sums, "sekai-linux-amd64.deb"); !ok || d != digestA {
+		t.Errorf("named lookup = %q, %t", d, ok)
+	}
+	if _, ok := LookupChecksum(sums, "sekai-linux-arm64.deb"); ok {
+		t.Error("found a checksum for an asset that is not listed")
+	}
+
+	// a bare digest stands for whatever asset the checksum file belongs to
+	sums[""] = digestB
+	if d, ok := LookupChecksum(sums, "sekai-linux-arm64.deb"); !ok || d != digestB {
+		t.Errorf("bare lookup = %q, %t", d, ok)
+	}
+	if d, _ := LookupChecksum(sums, "sekai-linux-amd64.deb"); d != digestA {
+		t.Errorf("a named digest must win over the bare one, got %q", d)
+	}
+}
+
+func TestFileSHA256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := FileSHA256(path); err != nil || got != digestA {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, err := FileSHA256(path + ".missing"); err == nil {
+		t.Error("missing file hashed")
+	}
+}
+
+func TestSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte(digestA + "  sekai-linux-amd64.deb\n")
+	sig := ed25519.Sign(priv, msg)
+
+	tests := []struct {
+		name    string
+		msg     []byte
+		sig     []byte
+		keys    []ed25519.PublicKey
+		wantErr string
+	}{
+		{name: "raw", msg: msg, sig: sig, keys: []ed25519.PublicKey{pub}},
+		{name: "base64", msg: msg, sig: []byte(base64.StdEncoding.EncodeToString(sig) + "\n"), keys: []ed25519.PublicKey{pub}},
+		{name: "hex", msg: msg, sig: []byte(hex.EncodeToString(sig)), keys: []ed25519.PublicKey{pub}},
+		{name: "any pinned key", msg: msg, sig: sig, keys: []ed25519.PublicKey{otherPub, pub}},
+		{name: "tampered", msg: append([]byte("x"), msg...), sig: sig, keys: []ed25519.PublicKey{pub}, wantErr: "does not match"},
+		{name: "untrusted signer", msg: msg, sig: ed25519.Sign(otherPriv, msg), keys: []ed25519.PublicKey{pub}, wantErr: "does not match"},
+		{name: "no keys", msg: msg, sig: sig, wantErr: "no trusted keys"},
+		{name: "malformed", msg: msg, sig: []byte("not a signature"), keys: []ed25519.PublicKey{pub}, wantErr: "expected 64 bytes"},
+	}
+	for _, tt := range tests {
+		err := Signature(tt.msg, tt.sig, tt.keys)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParsePublicKeys(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParsePublicKeys([]string{base64.StdEncoding.EncodeToString(pub), " " + hex.EncodeToString(pub) + " "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || !keys[0].Equal(pub) || !keys[1].Equal(pub) {
+		t.Errorf("got %v", keys)
+	}
+	if _, err := ParsePublicKeys([]string{hex.EncodeToString(pub[:16])}); err == nil {
+		t.Error("short key accepted")
+	}
+}
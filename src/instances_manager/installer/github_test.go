@@ -0,0 +1,176 @@
+package installer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/gitres"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/verify"
+)
+
+const testAssetName = "sekai-linux-amd64.tar.gz"
+
+// releaseStub serves release v0.4.1 of o/r with the given assets through the
+// GitHub API and their download URLs.
+func releaseStub(t *testing.T, assets map[string][]byte) *GitHub {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/o/r/releases/tags/v0.4.1" {
+			type asset struct {
+				Name string `json:"name"`
+				URL  string `json:"browser_download_url"`
+			}
+			rel := struct {
+				TagName string  `json:"tag_name"`
+				Assets  []asset `json:"assets"`
+			}{TagName: "v0.4.1"}
+			for name := range assets {
+				rel.Assets = append(rel.Assets, asset{Name: name, URL: srv.URL + "/dl/" + name})
+			}
+			sort.Slice(rel.Assets, func(i, j int) bool { return rel.Assets[i].Name < rel.Assets[j].Name })
+			_ = json.NewEncoder(w).Encode(rel)
+			return
+		}
+		body, ok := assets[strings.TrimPrefix(r.URL.Path, "/dl/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return &GitHub{
+		Owner:   "o",
+		Repo:    "r",
+		API:     &gitres.Client{HTTP: srv.Client(), BaseURL: srv.URL},
+		Client:  srv.Client(),
+		Options: InstallOptions{AssetRules: gitres.AssetRules{OS: "linux", Arch: "amd64"}},
+	}
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// errAny stands for an error of no particular kind.
+var errAny = errors.New("any error")
+
+func TestGitHubInstallChecksums(t *testing.T) {
+	asset, _ := makeAsset(t, 1<<10)
+	good, bad := sha256Hex(asset), sha256Hex([]byte("other"))
+
+	tests := []struct {
+		name     string
+		sums     map[string]string
+		insecure bool
+		want     string
+		wantErr  error
+	}{
+		{name: "per-asset file", sums: map[string]string{testAssetName + ".sha256": good + "  " + testAssetName + "\n"}, want: good},
+		{name: "per-asset file wins", sums: map[string]string{testAssetName + ".sha256": good + "\n", "sha256sums": bad + "  " + testAssetName + "\n"}, want: good},
+		{name: "sha256sums", sums: map[string]string{"sha256sums": bad + "  sekai-linux-arm64.tar.gz\n" + good + "  " + testAssetName + "\n"}, want: good},
+		{name: "sha256sums before SHA256SUMS", sums: map[string]string{"sha256sums.txt": good + " *" + testAssetName + "\n", "SHA256SUMS": bad + "  " + testAssetName + "\n"}, want: good},
+		{name: "checksums.txt", sums: map[string]string{"checksums.txt": good + "  " + testAssetName + "\n"}, want: good},
+		{name: "bare digest", sums: map[string]string{"SHA256SUMS.txt": good + "\n"}, want: good},
+		{name: "mismatch", sums: map[string]string{"sha256sums": bad + "  " + testAssetName + "\n"}, wantErr: verify.ErrChecksumMismatch},
+		{name: "asset not listed", sums: map[string]string{"sha256sums": good + "  sekai-linux-arm64.tar.gz\n"}, wantErr: errAny},
+		{name: "missing checksum", wantErr: errAny},
+		{name: "missing checksum, insecure", insecure: true, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assets := map[string][]byte{testAssetName: asset}
+			for name, body := range tt.sums {
+				assets[name] = []byte(body)
+			}
+			g := releaseStub(t, assets)
+			g.Options.InsecureSkipVerify = tt.insecure
+			binDir := filepath.Join(t.TempDir(), "bin")
+
+			res, err := g.Install(context.Background(), "v0.4.1", binDir)
+			if tt.wantErr != nil {
+				if err == nil || (tt.wantErr != errAny && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if _, err := os.Stat(binDir); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("bin dir left behind after a failed install: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := ""
+			if tt.want != "" {
+				want = "sha256:" + tt.want
+			}
+			if res.AssetDigest != want {
+				t.Errorf("AssetDigest %q, want %q", res.AssetDigest, want)
+			}
+		})
+	}
+}
+
+func TestGitHubInstallSignatures(t *testing.T) {
+	asset, _ := makeAsset(t, 1<<10)
+	sums := []byte(sha256Hex(asset) + "  " + testAssetName + "\n")
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := ed25519.Sign(priv, sums)
+	trusted := []string{base64.StdEncoding.EncodeToString(pub)}
+
+	tests := []struct {
+		name    string
+		sigs    map[string][]byte
+		keys    []string
+		wantErr string
+	}{
+		{name: ".sig", sigs: map[string][]byte{"sha256sums.sig": sig}, keys: trusted},
+		{name: ".ed25519", sigs: map[string][]byte{"sha256sums.ed25519": []byte(base64.StdEncoding.EncodeToString(sig))}, keys: trusted},
+		{name: ".sig wins over .ed25519", sigs: map[string][]byte{"sha256sums.sig": sig, "sha256sums.ed25519": []byte("garbage")}, keys: trusted},
+		{name: "no keys, no check", sigs: map[string][]byte{"sha256sums.sig": []byte("garbage")}},
+		{name: "invalid", sigs: map[string][]byte{"sha256sums.sig": ed25519.Sign(priv, []byte("other sums"))}, keys: trusted, wantErr: "does not match"},
+		{name: "untrusted", sigs: map[string][]byte{"sha256sums.sig": ed25519.Sign(otherPriv, sums)}, keys: trusted, wantErr: "does not match"},
+		{name: "unsigned", keys: trusted, wantErr: "has no signature"},
+		{name: "bad key", sigs: map[string][]byte{"sha256sums.sig": sig}, keys: []string{"nope"}, wantErr: "trusted keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assets := map[string][]byte{testAssetName: asset, "sha256sums": sums}
+			for name, body := range tt.sigs {
+				assets[name] = body
+			}
+			g := releaseStub(t, assets)
+			g.Options.TrustedKeys = tt.keys
+
+			_, err := g.Install(context.Background(), "v0.4.1", filepath.Join(t.TempDir(), "bin"))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatal(err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package installer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/archive"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/downloader"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/gitres"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/verify"
+)
+
+const (
+	SEKAI_OWNER     string = "KiraCore"
+	SEKAI_REPO      string = "sekai"
+	SEKAID_BIN_NAME string = "sekaid"
+
+	// DIGEST_FILE_NAME records the verified asset digest next to the installed binary.
+	DIGEST_FILE_NAME string = "asset.sha256"
+
+	maxChecksumFileSize = 1 << 20
+)
+
+// Installer puts a sekaid binary of some source into a directory.
+type Installer interface {
+	// Source identifies where binaries come from in the "<kind>:<location>" form
+	// accepted by FromSource; it is recorded with every instance installed by it.
+	Source() string
+	// Resolve turns the requested version into the exact version that Install gets.
+	Resolve(ctx context.Context, version string) (string, error)
+	// Install writes binDir/sekaid for the resolved version.
+	Install(ctx context.Context, version, binDir string) (*InstallResult, error)
+	// Reusable reports whether a binary once installed for a version may be reused
+	// for that version later, i.e. the source can not change underneath it.
+	Reusable() bool
+}
+
+// InstallOptions controls how downloaded artifacts are verified.
+type InstallOptions struct {
+	// TrustedKeys are pinned ed25519 public keys (base64 or hex). When set, the checksum
+	// file must carry a detached signature made by one of them.
+	TrustedKeys []string
+	// InsecureSkipVerify installs even if no checksum is published or given.
+	InsecureSkipVerify bool
+	// GitHub queries release metadata of github sources; FromSource uses
+	// gitres.NewClient when nil.
+	GitHub *gitres.Client
+	// AssetRules select the release asset, the running platform by default.
+	AssetRules gitres.AssetRules
+	// IncludePrerelease lets version constraints match prereleases.
+	IncludePrerelease bool
+	Verbose           bool
+	// Progress receives download progress of the release asset.
+	Progress func(downloader.Progress)
+	// DownloadDir keeps release assets while they download, so an interrupted
+	// download is resumed by the next install. Assets go to binDir when empty.
+	DownloadDir string
+}
+
+// InstallResult describes an installed sekaid.
+type InstallResult struct {
+	BinaryPath string
+	// AssetDigest is the verified sha256 of the downloaded asset, "sha256:<hex>".
+	// It is empty when the asset was installed without verification.
+	AssetDigest string
+}
+
+// releaseChecksum finds and (if keys are pinned) authenticates the checksum of asset.
+// It returns "" only when verification is skipped on request.
+func releaseChecksum(ctx context.Context, client *http.Client, assets []gitres.Asset, asset string, opts InstallOptions) (string, error) {
+	var sumsAsset gitres.Asset
+	found := false
+	for _, name := range verify.ChecksumAssetNames(asset) {
+		for _, a := range assets {
+			if a.Name == name {
+				sumsAsset, found = a, true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		if opts.InsecureSkipVerify {
+			fmt.Printf("WARNING: release has no checksum asset, %s is NOT verified\n", asset)
+			return "", nil
+		}
+		return "", fmt.Errorf("release has no checksum asset for %s (looked for %v)", asset, verify.ChecksumAssetNames(asset))
+	}
+
+	sums, err := downloader.Fetch(ctx, client, sumsAsset.URL, maxChecksumFileSize)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch %s: %w", sumsAsset.Name, err)
+	}
+
+	if len(opts.TrustedKeys) > 0 {
+		keys, err := verify.ParsePublicKeys(opts.TrustedKeys)
+		if err != nil {
+			return "", fmt.Errorf("trusted keys: %w", err)
+		}
+		if err := checkSignature(ctx, client, assets, sumsAsset.Name, sums, keys); err != nil {
+			return "", err
+		}
+		if opts.Verbose {
+			fmt.Println("Verified signature of", sumsAsset.Name)
+		}
+	}
+
+	parsed, err := verify.ParseChecksums(sums)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", sumsAsset.Name, err)
+	}
+	digest, ok := verify.LookupChecksum(parsed, asset)
+	if !ok {
+		return "", fmt.Errorf("%s has no checksum for %s", sumsAsset.Name, asset)
+	}
+	return digest, nil
+}
+
+func checkSignature(ctx context.Context, client *http.Client, assets []gitres.Asset, sumsName string, sums []byte, keys []ed25519.PublicKey) error {
+	for _, name := range verify.SignatureAssetNames(sumsName) {
+		for _, a := range assets {
+			if a.Name != name {
+				continue
+			}
+			sig, err := downloader.Fetch(ctx, client, a.URL, maxChecksumFileSize)
+			if err != nil {
+				return fmt.Errorf("unable to fetch %s: %w", a.Name, err)
+			}
+			if err := verify.Signature(sums, sig, keys); err != nil {
+				return fmt.Errorf("%s: %w", a.Name, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("trusted keys are configured but %s has no signature (looked for %v)", sumsName, verify.SignatureAssetNames(sumsName))
+}
+
+// inBinDir runs install with binDir created; if install fails and binDir did not
+// exist before, it is removed again so no partial binary is left behind.
+func inBinDir(binDir string, install func() (*InstallResult, error)) (*InstallResult, error) {
+	if _, err := os.Stat(binDir); errors.Is(err, os.ErrNotExist) {
+		res, err := mkdirAndInstall(binDir, install)
+		if err != nil {
+			_ = os.RemoveAll(binDir)
+		}
+		return res, err
+	}
+	return mkdirAndInstall(binDir, install)
+}
+
+func mkdirAndInstall(binDir string, install func() (*InstallResult, error)) (*InstallResult, error) {
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		return nil, err
+	}
+	return install()
+}
+
+// downloadAsset downloads url to a path that is the same for every install of the
+// asset, see InstallOptions.DownloadDir. An unfinished download is left there for
+// the next run; the caller removes the finished file with removeAsset.
+func downloadAsset(ctx context.Context, client *http.Client, url, name, binDir string, opts InstallOptions) (string, error) {
+	assetPath := filepath.Join(binDir, name)
+	if opts.DownloadDir != "" {
+		key := sha256.Sum256([]byte(url))
+		assetPath = filepath.Join(opts.DownloadDir, hex.EncodeToString(key[:8]), name)
+		if err := os.MkdirAll(filepath.Dir(assetPath), 0o755); err != nil {
+			return "", err
+		}
+	}
+	if err := downloader.Download(ctx, client, url, assetPath, downloader.Options{Progress: opts.Progress}); err != nil {
+		return "", fmt.Errorf("unable to download %s: %w", url, err)
+	}
+	return assetPath, nil
+}
+
+// removeAsset deletes a finished download and its folder under DownloadDir.
+func removeAsset(assetPath string, opts InstallOptions) {
+	_ = os.Remove(assetPath)
+	if opts.DownloadDir != "" {
+		_ = os.Remove(filepath.Dir(assetPath))
+	}
+}
+
+// extractBinary takes sekaid out of a downloaded or local asset into binDir,
+// whatever the asset format.
+func extractBinary(assetPath, binDir string) (string, error) {
+	out, err := archive.ExtractBinary(assetPath, []string{SEKAID_BIN_NAME}, binDir)
+	if err != nil {
+		return "", fmt.Errorf("unable to extract %s: %w", SEKAID_BIN_NAME, err)
+	}
+	return out, nil
+}
+
+// writeDigest records the verified asset digest next to the installed binary.
+// Nothing is written for an unverified asset.
+func writeDigest(binDir, digest string) error {
+	if digest == "" {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(binDir, DIGEST_FILE_NAME), []byte(digest+"\n"), 0o644)
+}
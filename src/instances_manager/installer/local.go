@@ -0,0 +1,59 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/verify"
+)
+
+// Local installs from the local filesystem: a release asset of any supported format,
+// a sekaid binary or a directory containing one.
+type Local struct {
+	Path    string
+	Options InstallOptions
+}
+
+func (l *Local) Source() string { return SOURCE_LOCAL + ":" + l.Path }
+
+// Reusable is false, the file may be replaced between installs.
+func (l *Local) Reusable() bool { return false }
+
+func (l *Local) Resolve(ctx context.Context, version string) (string, error) {
+	return exactVersion(l.Source(), version)
+}
+
+func (l *Local) Install(ctx context.Context, version, binDir string) (*InstallResult, error) {
+	src := l.Path
+	st, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if st.IsDir() {
+		src = filepath.Join(src, SEKAID_BIN_NAME)
+		if st, err = os.Stat(src); err != nil {
+			return nil, fmt.Errorf("%s has no %s: %w", l.Path, SEKAID_BIN_NAME, err)
+		}
+	}
+	if !st.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", src)
+	}
+	sum, err := verify.FileSHA256(src)
+	if err != nil {
+		return nil, err
+	}
+	if l.Options.Verbose {
+		fmt.Printf("Installing %s (sha256 %s)\n", src, sum)
+	}
+
+	return inBinDir(binDir, func() (*InstallResult, error) {
+		out, err := extractBinary(src, binDir)
+		if err != nil {
+			return nil, err
+		}
+		// nothing vouches for a local file, so no digest is recorded as verified
+		return &InstallResult{BinaryPath: out}, nil
+	})
+}
@@ -0,0 +1,36 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalInstallRecordsNoDigest(t *testing.T) {
+	asset, binary := makeAsset(t, 1<<10)
+	src := filepath.Join(t.TempDir(), "sekai-linux-amd64.tar.gz")
+	if err := os.WriteFile(src, asset, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	binDir := filepath.Join(t.TempDir(), "bin")
+	res, err := (&Local{Path: src}).Install(context.Background(), "v0.4.0", binDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AssetDigest != "" {
+		t.Errorf("AssetDigest %q for an unverified local file", res.AssetDigest)
+	}
+	if _, err := os.Stat(filepath.Join(binDir, DIGEST_FILE_NAME)); err == nil {
+		t.Error("digest file recorded for an unverified local file")
+	}
+	got, err := os.ReadFile(res.BinaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, binary) {
+		t.Error("installed binary differs from the one in the asset")
+	}
+}
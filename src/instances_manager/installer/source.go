@@ -0,0 +1,117 @@
+package installer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/gitres"
+)
+
+// Source kinds, written as "<kind>:<location>":
+//
+//	github:KiraCore/sekai                        release assets of a (forked) repository
+//	url:https://mirror/sekai.deb#sha256=<hex>    a single asset, checksum in the fragment
+//	local:/opt/sekai/sekai-linux-amd64.deb       a local asset, binary or directory holding sekaid
+//	build:/src/sekai                             go build of a local source checkout
+const (
+	SOURCE_GITHUB string = "github"
+	SOURCE_URL    string = "url"
+	SOURCE_LOCAL  string = "local"
+	SOURCE_BUILD  string = "build"
+)
+
+// DefaultSource is used when no source is given.
+var DefaultSource = SOURCE_GITHUB + ":" + SEKAI_OWNER + "/" + SEKAI_REPO
+
+// ParseSource splits a source into kind and location and checks the location.
+// An empty source is the DefaultSource.
+func ParseSource(source string) (kind, location string, err error) {
+	source = strings.TrimSpace(source)
+	if source == "" || source == SOURCE_GITHUB {
+		source = DefaultSource
+	}
+	kind, location, ok := strings.Cut(source, ":")
+	if !ok || location == "" {
+		return "", "", fmt.Errorf("invalid source %q, want <kind>:<location>", source)
+	}
+	switch kind {
+	case SOURCE_GITHUB:
+		owner, repo, ok := strings.Cut(location, "/")
+		if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return "", "", fmt.Errorf("invalid github source %q, want github:<owner>/<repo>", source)
+		}
+	case SOURCE_URL:
+		u, err := url.Parse(location)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", "", fmt.Errorf("invalid url source %q", source)
+		}
+		if _, err := urlChecksum(u); err != nil {
+			return "", "", err
+		}
+	case SOURCE_LOCAL, SOURCE_BUILD:
+		if !filepath.IsAbs(location) {
+			abs, err := filepath.Abs(location)
+			if err != nil {
+				return "", "", err
+			}
+			location = abs
+		}
+	default:
+		return "", "", fmt.Errorf("unknown source kind %q (want %s, %s, %s or %s)", kind, SOURCE_GITHUB, SOURCE_URL, SOURCE_LOCAL, SOURCE_BUILD)
+	}
+	return kind, location, nil
+}
+
+// FromSource returns the Installer for source (see ParseSource).
+func FromSource(source string, client *http.Client, opts InstallOptions) (Installer, error) {
+	kind, location, err := ParseSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	switch kind {
+	case SOURCE_GITHUB:
+		owner, repo, _ := strings.Cut(location, "/")
+		api := opts.GitHub
+		if api == nil {
+			api = gitres.NewClient(client)
+		}
+		return &GitHub{Owner: owner, Repo: repo, API: api, Client: client, Options: opts}, nil
+	case SOURCE_URL:
+		u, _ := url.Parse(location)
+		sum, _ := urlChecksum(u)
+		u.Fragment = ""
+		return &URL{URL: u.String(), Checksum: sum, Client: client, Options: opts}, nil
+	case SOURCE_LOCAL:
+		return &Local{Path: location, Options: opts}, nil
+	default:
+		return &Build{Dir: location, Options: opts}, nil
+	}
+}
+
+// urlChecksum reads the "#sha256=<hex>" fragment of a url source.
+func urlChecksum(u *url.URL) (string, error) {
+	if u.Fragment == "" {
+		return "", nil
+	}
+	sum, ok := strings.CutPrefix(u.Fragment, "sha256=")
+	if !ok || len(sum) != 64 {
+		return "", fmt.Errorf("invalid url source fragment %q, want sha256=<hex>", u.Fragment)
+	}
+	return strings.ToLower(sum), nil
+}
+
+// exactVersion is the Resolve of sources that carry no version information:
+// the requested version is taken as a label and must be exact.
+func exactVersion(source, version string) (string, error) {
+	version = strings.TrimSpace(version)
+	if !gitres.IsExactVersion(version) {
+		return "", fmt.Errorf("source %s needs an exact sekaid version, got %q", source, version)
+	}
+	return version, nil
+}
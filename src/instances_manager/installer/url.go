@@ -0,0 +1,84 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/verify"
+)
+
+// URL installs a single asset from a direct link, e.g. an air-gapped mirror.
+type URL struct {
+	URL string
+	// Checksum is the expected hex sha256 of the asset.
+	Checksum string
+	Client   *http.Client
+	Options  InstallOptions
+}
+
+func (u *URL) Source() string {
+	if u.Checksum == "" {
+		return SOURCE_URL + ":" + u.URL
+	}
+	return SOURCE_URL + ":" + u.URL + "#sha256=" + u.Checksum
+}
+
+// Reusable is true because the asset is pinned by its checksum (or by the url when
+// verification is skipped on request).
+func (u *URL) Reusable() bool { return true }
+
+func (u *URL) Resolve(ctx context.Context, version string) (string, error) {
+	return exactVersion(u.Source(), version)
+}
+
+// Install downloads the asset, checks it against Checksum and extracts sekaid.
+func (u *URL) Install(ctx context.Context, version, binDir string) (*InstallResult, error) {
+	if u.Checksum == "" && !u.Options.InsecureSkipVerify {
+		return nil, fmt.Errorf("url source has no checksum, append #sha256=<hex> to %s", u.URL)
+	}
+	parsed, err := url.Parse(u.URL)
+	if err != nil {
+		return nil, err
+	}
+	name := path.Base(parsed.Path)
+	if name == "/" || name == "." {
+		return nil, fmt.Errorf("url %s names no file", u.URL)
+	}
+
+	return inBinDir(binDir, func() (*InstallResult, error) {
+		assetPath, err := downloadAsset(ctx, u.Client, u.URL, name, binDir, u.Options)
+		if err != nil {
+			return nil, err
+		}
+		defer removeAsset(assetPath, u.Options)
+		gotDigest, err := verify.FileSHA256(assetPath)
+		if err != nil {
+			return nil, err
+		}
+		if u.Checksum == "" {
+			fmt.Printf("WARNING: no checksum given, %s is NOT verified\n", name)
+		} else if !strings.EqualFold(u.Checksum, gotDigest) {
+			return nil, fmt.Errorf("%w for %s: want %s, got %s", verify.ErrChecksumMismatch, name, u.Checksum, gotDigest)
+		}
+
+		out, err := extractBinary(assetPath, binDir)
+		if err != nil {
+			return nil, err
+		}
+		// a digest that was not checked against the url checksum is not recorded
+		var digest string
+		if u.Checksum != "" {
+			digest = "sha256:" + gotDigest
+		}
+		if err := writeDigest(binDir, digest); err != nil {
+			_ = os.Remove(out)
+			return nil, err
+		}
+		return &InstallResult{BinaryPath: out, AssetDigest: digest}, nil
+	})
+}
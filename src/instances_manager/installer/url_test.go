@@ -0,0 +1,189 @@
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// makeAsset returns a tar.gz holding a sekaid of n random bytes.
+func makeAsset(t *testing.T, n int) (asset, binary []byte) {
+	t.Helper()
+	binary = make([]byte, n)
+	if _, err := rand.Read(binary); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "bin/" + SEKAID_BIN_NAME, Mode: 0o755, Size: int64(n), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(binary); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), binary
+}
+
+// flakyServer serves asset with an ETag. While broken it drops the first response
+// half way and answers everything after it with 404, so the download fails for good.
+type flakyServer struct {
+	mu     sync.Mutex
+	asset  []byte
+	broken bool
+	served int
+	ranges []string
+}
+
+func (f *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	broken, served := f.broken, f.served
+	f.served++
+	f.ranges = append(f.ranges, r.Header.Get("Range"))
+	f.mu.Unlock()
+
+	if broken && served > 0 {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("ETag", `"asset-1"`)
+	if broken {
+		w.Header().Set("Content-Length", "999999999")
+		_, _ = w.Write(f.asset[:len(f.asset)/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(f.asset))
+}
+
+func TestURLInstallResumesAcrossRuns(t *testing.T) {
+	asset, binary := makeAsset(t, 64<<10)
+	sum := sha256.Sum256(asset)
+	f := &flakyServer{asset: asset, broken: true}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	downloads := filepath.Join(dir, "downloads")
+	u := &URL{
+		URL:      srv.URL + "/sekai-linux-amd64.tar.gz",
+		Checksum: hex.EncodeToString(sum[:]),
+		Client:   srv.Client(),
+		Options:  InstallOptions{DownloadDir: downloads},
+	}
+
+	// the first run fails in its own staging dir, like a store install
+	binDir := filepath.Join(dir, "stage-1")
+	if _, err := u.Install(context.Background(), "v0.4.0", binDir); err == nil {
+		t.Fatal("broken download succeeded")
+	}
+	if _, err := os.Stat(binDir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("failed install left its bin dir behind")
+	}
+	partials, _ := filepath.Glob(filepath.Join(downloads, "*", "*.partial"))
+	if len(partials) != 1 {
+		t.Fatalf("want one partial download kept, got %v", partials)
+	}
+
+	f.mu.Lock()
+	f.broken, f.served, f.ranges = false, 0, nil
+	f.mu.Unlock()
+	binDir = filepath.Join(dir, "stage-2")
+	res, err := u.Install(context.Background(), "v0.4.0", binDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	ranges := f.ranges
+	f.mu.Unlock()
+	if want := fmt.Sprintf("bytes=%d-", len(asset)/2); len(ranges) != 1 || ranges[0] != want {
+		t.Fatalf("second run sent Range %q, want %q", ranges, want)
+	}
+	got, err := os.ReadFile(res.BinaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, binary) {
+		t.Fatal("installed binary differs")
+	}
+	if left, _ := filepath.Glob(filepath.Join(downloads, "*")); len(left) != 0 {
+		t.Fatalf("finished download left %v behind", left)
+	}
+}
+
+func TestURLInstallDropsCorruptDownload(t *testing.T) {
+	asset, _ := makeAsset(t, 1<<10)
+	srv := httptest.NewServer(&flakyServer{asset: asset})
+	t.Cleanup(srv.Close)
+
+	downloads := filepath.Join(t.TempDir(), "downloads")
+	u := &URL{
+		URL:      srv.URL + "/sekai-linux-amd64.tar.gz",
+		Checksum: hex.EncodeToString(make([]byte, sha256.Size)),
+		Client:   srv.Client(),
+		Options:  InstallOptions{DownloadDir: downloads},
+	}
+	if _, err := u.Install(context.Background(), "v0.4.0", filepath.Join(t.TempDir(), "bin")); err == nil {
+		t.Fatal("checksum mismatch was installed")
+	}
+	if left, _ := filepath.Glob(filepath.Join(downloads, "*")); len(left) != 0 {
+		t.Fatalf("rejected download left %v behind", left)
+	}
+}
+
+func TestURLInstallRecordsOnlyVerifiedDigest(t *testing.T) {
+	asset, _ := makeAsset(t, 1<<10)
+	sum := sha256.Sum256(asset)
+	srv := httptest.NewServer(&flakyServer{asset: asset})
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name     string
+		checksum string
+		want     string
+	}{
+		{name: "verified", checksum: hex.EncodeToString(sum[:]), want: "sha256:" + hex.EncodeToString(sum[:])},
+		{name: "insecure", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &URL{
+				URL:      srv.URL + "/sekai-linux-amd64.tar.gz",
+				Checksum: tt.checksum,
+				Client:   srv.Client(),
+				Options:  InstallOptions{InsecureSkipVerify: tt.checksum == ""},
+			}
+			binDir := filepath.Join(t.TempDir(), "bin")
+			res, err := u.Install(context.Background(), "v0.4.0", binDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.AssetDigest != tt.want {
+				t.Fatalf("AssetDigest %q, want %q", res.AssetDigest, tt.want)
+			}
+			_, err = os.Stat(filepath.Join(binDir, DIGEST_FILE_NAME))
+			if recorded := err == nil; recorded != (tt.want != "") {
+				t.Fatalf("digest file recorded: %t", recorded)
+			}
+		})
+	}
+}
@@ -1,26 +1,232 @@
 package instancesmanager
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
 	"github.com/PeepoFrog/sekai_manager/src/cfg"
 	"github.com/PeepoFrog/sekai_manager/src/types"
 )
 
+// instanceNameRe limits names to something safe to use as a folder name and in file names.
+var instanceNameRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}$`)
+
+var (
+	ErrInstanceExists   = errors.New("instance already exists")
+	ErrInstanceNotFound = errors.New("instance not found")
+	ErrInvalidName      = errors.New("invalid instance name")
+)
+
 type InstanceManager struct {
 	*types.ManagerConfig
+
+	mu sync.Mutex
 }
 
+// NewInstanceManager loads the manager config from the default location.
 func NewInstanceManager() (*InstanceManager, error) {
-	ic, err := cfg.DefaultCfg()
+	ic, err := cfg.LoadConfig("", ConfigValidators)
 	if err != nil {
 		return nil, err
 	}
 	return &InstanceManager{ManagerConfig: ic}, nil
 }
 
+// NewInstanceManagerFromConfig wraps an already loaded manager config.
+func NewInstanceManagerFromConfig(mc *types.ManagerConfig) *InstanceManager {
+	return &InstanceManager{ManagerConfig: mc}
+}
+
+// ValidateInstanceName reports whether name can be used for a new instance.
+func ValidateInstanceName(name string) error {
+	if !instanceNameRe.MatchString(name) {
+		return fmt.Errorf("%w %q: must match %s", ErrInvalidName, name, instanceNameRe.String())
+	}
+	return nil
+}
+
+// InstanceHome returns the default home folder for an instance with the given name.
+func (im *InstanceManager) InstanceHome(name string) string {
+	return filepath.Join(im.Home, cfg.INSTANCES_FOLDER_NAME, name)
+}
+
+// CreateInstance creates the instance home, allocates a free port range and
+// persists the new instance into the manager config.
+// On failure every partial change (folder, config entry) is rolled back.
 func (im *InstanceManager) CreateInstance(name string) error {
+	if err := ValidateInstanceName(name); err != nil {
+		return err
+	}
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	if _, idx := im.findInstance(name); idx >= 0 {
+		return fmt.Errorf("%w: %s", ErrInstanceExists, name)
+	}
+
+	home := im.InstanceHome(name)
+	if _, err := os.Stat(home); err == nil {
+		return fmt.Errorf("%w: home folder %s already exists", ErrInstanceExists, home)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.MkdirAll(home, 0o700); err != nil {
+		return fmt.Errorf("unable to create instance home: %w", err)
+	}
+
+	portRange, binding, err := im.allocatePortRange()
+	if err != nil {
+		_ = os.RemoveAll(home)
+		return err
+	}
+
+	prev := im.Instances
+	im.Instances = append(append([]types.InstanceConfig(nil), prev...), types.InstanceConfig{
+		Name:      name,
+		Home:      home,
+		PortRange: portRange,
+		Addresses: binding.Record(),
+	})
+
+	if _, err := cfg.GenerateConfigFile(im.ManagerConfig); err != nil {
+		im.Instances = prev
+		_ = os.RemoveAll(home)
+		return fmt.Errorf("unable to persist config: %w", err)
+	}
 	return nil
 }
 
-func (im *InstanceManager) ListInstances() (*[]types.InstanceConfig, error) {
-	return nil, nil
+// GetInstance returns a copy of the registered instance config.
+func (im *InstanceManager) GetInstance(name string) (types.InstanceConfig, error) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	ic, idx := im.findInstance(name)
+	if idx < 0 {
+		return types.InstanceConfig{}, fmt.Errorf("%w: %s", ErrInstanceNotFound, name)
+	}
+	return ic, nil
+}
+
+// UpdateInstance replaces the registered entry with the same name and persists the config.
+func (im *InstanceManager) UpdateInstance(ic types.InstanceConfig) error {
+	if err := cfg.ValidateInstanceConfig(ic, ConfigValidators); err != nil {
+		return fmt.Errorf("instance %q: %w", ic.Name, err)
+	}
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	_, idx := im.findInstance(ic.Name)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrInstanceNotFound, ic.Name)
+	}
+	prev := im.Instances
+	im.Instances = append([]types.InstanceConfig(nil), prev...)
+	im.Instances[idx] = ic
+	if err := cfg.CheckPortCollisions(im.Instances); err != nil {
+		im.Instances = prev
+		return err
+	}
+
+	if _, err := cfg.GenerateConfigFile(im.ManagerConfig); err != nil {
+		im.Instances = prev
+		return fmt.Errorf("unable to persist config: %w", err)
+	}
+	return nil
+}
+
+// DiscardInstance unregisters an instance and deletes its home folder.
+// It is meant to roll back an instance whose initialization failed; it neither
+// stops the process nor keeps a copy of the keys.
+func (im *InstanceManager) DiscardInstance(name string) error {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	ic, err := im.unregister(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(ic.Home)
+}
+
+// unregister drops the instance from the registry and persists the config,
+// which also frees its port range. Must be called with im.mu held.
+func (im *InstanceManager) unregister(name string) (types.InstanceConfig, error) {
+	ic, idx := im.findInstance(name)
+	if idx < 0 {
+		return ic, fmt.Errorf("%w: %s", ErrInstanceNotFound, name)
+	}
+	prev := im.Instances
+	im.Instances = append(append([]types.InstanceConfig(nil), prev[:idx]...), prev[idx+1:]...)
+	if _, err := cfg.GenerateConfigFile(im.ManagerConfig); err != nil {
+		im.Instances = prev
+		return ic, fmt.Errorf("unable to persist config: %w", err)
+	}
+	return ic, nil
+}
+
+// findInstance must be called with im.mu held.
+func (im *InstanceManager) findInstance(name string) (types.InstanceConfig, int) {
+	for i, ic := range im.Instances {
+		if ic.Name == name {
+			return ic, i
+		}
+	}
+	return types.InstanceConfig{}, -1
+}
+
+// allocatePortRange returns the lowest port range that is neither registered
+// nor collides with the (possibly hand-edited) bindings of existing instances,
+// including ranges whose shifted ports land on another range's defaults.
+// Must be called with im.mu held.
+func (im *InstanceManager) allocatePortRange() (int, cfg.AddressBinding, error) {
+	used := make(map[int]struct{}, len(im.Instances))
+	for _, ic := range im.Instances {
+		used[ic.PortRange] = struct{}{}
+	}
+	for n := 0; n <= cfg.MaxPortRange(); n++ {
+		if _, ok := used[n]; ok {
+			continue
+		}
+		ab, err := cfg.AddressBindingForRange(n)
+		if err != nil {
+			return 0, cfg.AddressBinding{}, err
+		}
+		candidate := append(append([]types.InstanceConfig(nil), im.Instances...), types.InstanceConfig{
+			Name:      newInstancePlaceholder,
+			PortRange: n,
+			Addresses: ab.Record(),
+		})
+		collisions, err := cfg.FindPortCollisions(candidate)
+		if err != nil {
+			return 0, cfg.AddressBinding{}, err
+		}
+		if !collidesWith(collisions, newInstancePlaceholder) {
+			return n, ab, nil
+		}
+	}
+	return 0, cfg.AddressBinding{}, errors.New("no free port range left")
+}
+
+// newInstancePlaceholder names the candidate instance during port allocation.
+// It can never clash with a real instance as it fails ValidateInstanceName.
+const newInstancePlaceholder = "<new>"
+
+func collidesWith(collisions []cfg.PortCollision, name string) bool {
+	for _, c := range collisions {
+		for _, u := range c.Users {
+			if strings.HasPrefix(u, name+"/") {
+				return true
+			}
+		}
+	}
+	return false
 }
@@ -0,0 +1,143 @@
+package instancesmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PeepoFrog/sekai_manager/src/cfg"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+func TestAllocatePortRangeSkipsOverlappingRanges(t *testing.T) {
+	// pprof of range 206 is the prometheus port of range 0
+	ab, err := cfg.AddressBindingForRange(206)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := NewInstanceManagerFromConfig(&types.ManagerConfig{Instances: []types.InstanceConfig{
+		{Name: "high", PortRange: 206, Addresses: ab.Record()},
+	}})
+
+	n, ab, err := im.allocatePortRange()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("allocated range %d, want 1", n)
+	}
+	im.Instances = append(im.Instances, types.InstanceConfig{Name: "new", PortRange: n, Addresses: ab.Record()})
+	if err := cfg.CheckPortCollisions(im.Instances); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testManager returns a manager with an empty config under a temp home.
+func testManager(t *testing.T) *InstanceManager {
+	t.Helper()
+	home := t.TempDir()
+	return NewInstanceManagerFromConfig(&types.ManagerConfig{
+		SchemaVersion: cfg.CURRENT_SCHEMA_VERSION,
+		Home:          home,
+		ConfigPath:    filepath.Join(home, cfg.MANAGER_CONFIG_FILE_NAME),
+	})
+}
+
+func TestCreateInstance(t *testing.T) {
+	im := testManager(t)
+	for _, name := range []string{"node-1", "node_2"} {
+		if err := im.CreateInstance(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st, err := os.Stat(im.InstanceHome("node-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.IsDir() || st.Mode().Perm() != 0o700 {
+		t.Errorf("instance home mode %v, want a 0700 directory", st.Mode())
+	}
+
+	loaded, err := cfg.LoadConfig(im.ConfigPath, ConfigValidators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Instances) != 2 {
+		t.Fatalf("%d instances persisted, want 2", len(loaded.Instances))
+	}
+	for i, ic := range loaded.Instances {
+		if ic.PortRange != i || ic.Home != im.InstanceHome(ic.Name) {
+			t.Errorf("instance %s: range %d, home %s", ic.Name, ic.PortRange, ic.Home)
+		}
+	}
+}
+
+func TestCreateInstanceInvalidName(t *testing.T) {
+	im := testManager(t)
+	for _, name := range []string{"", "-node", "_node", "a/b", "../x", "a b", "node.1", strings.Repeat("a", 65)} {
+		if err := im.CreateInstance(name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%q: error = %v, want ErrInvalidName", name, err)
+		}
+	}
+	if err := im.CreateInstance(strings.Repeat("a", 64)); err != nil {
+		t.Errorf("64 character name: %v", err)
+	}
+	if len(im.Instances) != 1 {
+		t.Errorf("%d instances registered, want 1", len(im.Instances))
+	}
+}
+
+func TestCreateInstanceRejectsDuplicates(t *testing.T) {
+	im := testManager(t)
+	if err := im.CreateInstance("node"); err != nil {
+		t.Fatal(err)
+	}
+	if err := im.CreateInstance("node"); !errors.Is(err, ErrInstanceExists) {
+		t.Errorf("duplicate name: error = %v, want ErrInstanceExists", err)
+	}
+
+	// a folder nobody registered is not taken over
+	stray := im.InstanceHome("stray")
+	if err := os.MkdirAll(stray, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(stray, "keep")
+	if err := os.WriteFile(marker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := im.CreateInstance("stray"); !errors.Is(err, ErrInstanceExists) {
+		t.Errorf("existing home: error = %v, want ErrInstanceExists", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("existing home was touched: %v", err)
+	}
+	if len(im.Instances) != 1 {
+		t.Errorf("%d instances registered, want 1", len(im.Instances))
+	}
+}
+
+func TestCreateInstanceRollsBack(t *testing.T) {
+	im := testManager(t)
+	if err := im.CreateInstance("node"); err != nil {
+		t.Fatal(err)
+	}
+	before := append([]types.InstanceConfig(nil), im.Instances...)
+
+	// the config can not be written into a folder that does not exist
+	im.ConfigPath = filepath.Join(im.Home, "missing", cfg.MANAGER_CONFIG_FILE_NAME)
+	if err := im.CreateInstance("other"); err == nil {
+		t.Fatal("created an instance without persisting the config")
+	}
+	if len(im.Instances) != len(before) || im.Instances[0].Name != before[0].Name {
+		t.Errorf("instances %v after a failed create, want %v", im.Instances, before)
+	}
+	if _, err := os.Stat(im.InstanceHome("other")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("instance home left behind: %v", err)
+	}
+	if _, err := os.Stat(im.InstanceHome("node")); err != nil {
+		t.Errorf("existing instance home removed: %v", err)
+	}
+}
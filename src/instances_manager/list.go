@@ -0,0 +1,138 @@
+package instancesmanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/PeepoFrog/sekai_manager/src/cfg"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/supervisor"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+// InstanceDescriptor is an InstanceConfig together with facts derived from disk and the supervisor.
+type InstanceDescriptor struct {
+	Config types.InstanceConfig `json:"config"`
+
+	BinaryPath string              `json:"binary_path"`
+	Version    string              `json:"version"`
+	Ports      []cfg.NamedPortPair `json:"ports"`
+	HomeExists bool                `json:"home_exists"`
+	KeysExist  bool                `json:"keys_exist"`
+	State      supervisor.State    `json:"state"`
+	Pid        int                 `json:"pid,omitempty"`
+}
+
+// ListFilter narrows ListInstances. Zero values match everything.
+type ListFilter struct {
+	// NameGlob is matched with path.Match, e.g. "val-*".
+	NameGlob string
+	// States keeps only instances in one of the given run states.
+	States []supervisor.State
+}
+
+func (f ListFilter) matchName(name string) (bool, error) {
+	if f.NameGlob == "" {
+		return true, nil
+	}
+	return path.Match(f.NameGlob, name)
+}
+
+func (f ListFilter) matchState(state supervisor.State) bool {
+	if len(f.States) == 0 {
+		return true
+	}
+	for _, s := range f.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// ListInstances returns descriptors of the registered instances that pass the filter, in registry order.
+func (im *InstanceManager) ListInstances(filter ListFilter) ([]InstanceDescriptor, error) {
+	im.mu.Lock()
+	instances := append([]types.InstanceConfig(nil), im.Instances...)
+	im.mu.Unlock()
+
+	sv := supervisor.New(im.Home)
+	var out []InstanceDescriptor
+	for _, ic := range instances {
+		ok, err := filter.matchName(ic.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name filter %q: %w", filter.NameGlob, err)
+		}
+		if !ok {
+			continue
+		}
+		d, err := describeInstance(sv, ic)
+		if err != nil {
+			return nil, fmt.Errorf("instance %q: %w", ic.Name, err)
+		}
+		if !filter.matchState(d.State) {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func describeInstance(sv *supervisor.Supervisor, ic types.InstanceConfig) (InstanceDescriptor, error) {
+	d := InstanceDescriptor{Config: ic, Version: ic.ResolvedVersion}
+	if d.Version == "" {
+		d.Version = ic.SekaidVersion
+	}
+
+	// a missing binary is a fact to report, not an error
+	if bin, err := supervisor.BinaryPath(ic); err == nil {
+		d.BinaryPath = bin
+	}
+
+	ab, err := cfg.BindingOf(ic)
+	if err != nil {
+		return d, err
+	}
+	if d.Ports, err = PortsInUse(ab); err != nil {
+		return d, err
+	}
+
+	d.HomeExists = isDir(ic.Home)
+	d.KeysExist = isFile(filepath.Join(ic.Home, "config", "priv_validator_key.json")) &&
+		isFile(filepath.Join(ic.Home, "config", "node_key.json"))
+
+	if d.State, d.Pid, err = sv.State(ic.Name); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+// PortsInUse returns the listening ports of a binding, i.e. PortPairsList without the client node address.
+func PortsInUse(ab cfg.AddressBinding) ([]cfg.NamedPortPair, error) {
+	pairs, err := cfg.PortPairsList(ab)
+	if err != nil {
+		return nil, err
+	}
+	out := pairs[:0]
+	for _, pp := range pairs {
+		if pp.Name != "node" {
+			out = append(out, pp)
+		}
+	}
+	return out, nil
+}
+
+func isDir(p string) bool {
+	st, err := os.Stat(p)
+	return err == nil && st.IsDir()
+}
+
+func isFile(p string) bool {
+	st, err := os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	return err == nil && st.Mode().IsRegular()
+}
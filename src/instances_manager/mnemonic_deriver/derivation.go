@@ -0,0 +1,135 @@
+package mnemonicderiver
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	"github.com/cosmos/go-bip39"
+)
+
+// Derivation schemes. DERIVATION_MASTER is the set GenerateMnemonicsFromMaster
+// derives from the master itself; the others first derive a child master per
+// index or per name, so several instances get distinct keys from one master.
+const (
+	DERIVATION_MASTER string = "master"
+	DERIVATION_INDEX  string = "index"
+	DERIVATION_NAME   string = "name"
+)
+
+// childDomain separates child master derivation from any other use of the master.
+const childDomain = "sekai_manager/child-master/v1"
+
+// Derivation is everything needed to reproduce an instance's keys from the
+// master. types.KeyDerivation mirrors it for instance configs.
+type Derivation struct {
+	Scheme string
+	Index  uint32
+	Name   string
+	Prefix string
+	Path   string
+}
+
+// ParseDerivation reads "master", "index:<n>" or "name:<name>". Prefix and Path
+// are left for the caller.
+func ParseDerivation(spec string) (Derivation, error) {
+	scheme, value, _ := strings.Cut(spec, ":")
+	d := Derivation{Scheme: scheme}
+	switch scheme {
+	case "", DERIVATION_MASTER:
+		if value != "" {
+			return d, fmt.Errorf("derivation %q takes no value", DERIVATION_MASTER)
+		}
+		d.Scheme = DERIVATION_MASTER
+	case DERIVATION_INDEX:
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return d, fmt.Errorf("invalid derivation index %q", value)
+		}
+		d.Index = uint32(n)
+	case DERIVATION_NAME:
+		d.Name = value
+	default:
+		return d, fmt.Errorf("unknown derivation %q (use %s, %s:<n> or %s:<name>)", spec, DERIVATION_MASTER, DERIVATION_INDEX, DERIVATION_NAME)
+	}
+	return d, d.Validate()
+}
+
+// Validate checks the scheme and its value; an empty scheme means DERIVATION_MASTER.
+func (d Derivation) Validate() error {
+	switch d.Scheme {
+	case "", DERIVATION_MASTER:
+		if d.Index != 0 || d.Name != "" {
+			return fmt.Errorf("derivation %q takes no index or name", DERIVATION_MASTER)
+		}
+	case DERIVATION_INDEX:
+		if d.Name != "" {
+			return fmt.Errorf("derivation %q takes no name", DERIVATION_INDEX)
+		}
+	case DERIVATION_NAME:
+		if strings.TrimSpace(d.Name) == "" {
+			return errors.New("derivation name is empty")
+		}
+		if d.Index != 0 {
+			return fmt.Errorf("derivation %q takes no index", DERIVATION_NAME)
+		}
+	default:
+		return fmt.Errorf("unknown derivation scheme %q", d.Scheme)
+	}
+	return nil
+}
+
+// String renders d the way ParseDerivation reads it.
+func (d Derivation) String() string {
+	switch d.Scheme {
+	case DERIVATION_INDEX:
+		return fmt.Sprintf("%s:%d", DERIVATION_INDEX, d.Index)
+	case DERIVATION_NAME:
+		return DERIVATION_NAME + ":" + d.Name
+	}
+	return DERIVATION_MASTER
+}
+
+// ChildMasterMnemonic returns the 24-word master the keys of d are derived from:
+// the master itself for DERIVATION_MASTER, otherwise
+// sha256(domain | master | scheme | value) as BIP39 entropy. Index and name
+// children never collide since the scheme is part of the hash.
+func ChildMasterMnemonic(masterMnemonic string, d Derivation) (string, error) {
+	if err := d.Validate(); err != nil {
+		return "", err
+	}
+	if d.Scheme == "" || d.Scheme == DERIVATION_MASTER {
+		return masterMnemonic, nil
+	}
+	value := d.Name
+	if d.Scheme == DERIVATION_INDEX {
+		value = strconv.FormatUint(uint64(d.Index), 10)
+	}
+	normalized := strings.Join(strings.Fields(strings.ToLower(masterMnemonic)), " ")
+	h := sha256.New()
+	for _, part := range []string{childDomain, normalized, d.Scheme, value} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return bip39.NewMnemonic(h.Sum(nil))
+}
+
+// GenerateMnemonicsForDerivation derives the mnemonic set selected by d, using
+// d.Prefix and d.Path (vlg defaults when empty).
+func GenerateMnemonicsForDerivation(masterMnemonic string, d Derivation) (*vlg.MasterMnemonicSet, error) {
+	child, err := ChildMasterMnemonic(masterMnemonic, d)
+	if err != nil {
+		return nil, err
+	}
+	prefix, path := d.Prefix, d.Path
+	if prefix == "" {
+		prefix = vlg.DefaultPrefix
+	}
+	if path == "" {
+		path = vlg.DefaultPath
+	}
+	return GenerateMnemonicsFromMaster(child, prefix, path)
+}
@@ -0,0 +1,160 @@
+package mnemonicderiver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+)
+
+const derivationMaster = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+
+func TestParseDerivation(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Derivation
+		wantErr bool
+	}{
+		{spec: "", want: Derivation{Scheme: DERIVATION_MASTER}},
+		{spec: "master", want: Derivation{Scheme: DERIVATION_MASTER}},
+		{spec: "index:0", want: Derivation{Scheme: DERIVATION_INDEX}},
+		{spec: "index:4294967295", want: Derivation{Scheme: DERIVATION_INDEX, Index: 4294967295}},
+		{spec: "name:validator-1", want: Derivation{Scheme: DERIVATION_NAME, Name: "validator-1"}},
+		{spec: "name:a:b", want: Derivation{Scheme: DERIVATION_NAME, Name: "a:b"}},
+		{spec: "master:1", wantErr: true},
+		{spec: "index:", wantErr: true},
+		{spec: "index:-1", wantErr: true},
+		{spec: "index:4294967296", wantErr: true},
+		{spec: "name:", wantErr: true},
+		{spec: "name: ", wantErr: true},
+		{spec: "path:0", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDerivation(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.spec, got, tt.want)
+		}
+		if again, err := ParseDerivation(got.String()); err != nil || again != got {
+			t.Errorf("%q: String %q does not parse back: %+v, %v", tt.spec, got.String(), again, err)
+		}
+	}
+}
+
+func TestDerivationValidate(t *testing.T) {
+	for _, d := range []Derivation{
+		{Scheme: DERIVATION_MASTER, Index: 1},
+		{Scheme: DERIVATION_MASTER, Name: "x"},
+		{Scheme: DERIVATION_INDEX, Name: "x"},
+		{Scheme: DERIVATION_NAME, Name: "x", Index: 1},
+		{Scheme: "other"},
+	} {
+		if err := d.Validate(); err == nil {
+			t.Errorf("%+v accepted", d)
+		}
+	}
+}
+
+func TestChildMasterMnemonic(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{spec: "master", want: derivationMaster},
+		{spec: "index:0", want: "squeeze finish gaze drip gallery shrimp ripple autumn dance kidney tissue rib shrimp sad scorpion feed dentist gloom bus exchange always reject swarm image"},
+		{spec: "index:1", want: "very course odor coach oil either morning duck guilt luggage ensure misery try buzz basket supreme song carpet shop bean process they ivory emotion"},
+		{spec: "name:1", want: "stand document south spawn dune soup cram heart cruel music movie awkward circle cable leopard master indicate goddess donkey employ art castle kick half"},
+		{spec: "name:validator-1", want: "grab cattle trip lion gauge category pupil shy session doll guilt brisk across random carry two letter dilemma poet mandate clinic oak answer rabbit"},
+	}
+	for _, tt := range tests {
+		d, err := ParseDerivation(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ChildMasterMnemonic(derivationMaster, d)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.spec, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.spec, got, tt.want)
+		}
+		// the master's spelling does not matter, only its words
+		loose := "  " + strings.ToUpper(strings.ReplaceAll(derivationMaster, " ", "\n  ")) + " "
+		if d.Scheme != DERIVATION_MASTER {
+			if again, _ := ChildMasterMnemonic(loose, d); again != got {
+				t.Errorf("%s: child of the unnormalized master differs", tt.spec)
+			}
+		}
+	}
+}
+
+// The child entropy is the documented sha256(domain | master | scheme | value).
+func TestChildMasterEntropy(t *testing.T) {
+	h := sha256.New()
+	for _, part := range []string{"sekai_manager/child-master/v1", derivationMaster, "index", "0"} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	child, err := ChildMasterMnemonic(derivationMaster, Derivation{Scheme: DERIVATION_INDEX})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entropy, err := mnemonicEntropy(child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(entropy), hex.EncodeToString(h.Sum(nil)); got != want {
+		t.Errorf("child entropy %s, want %s", got, want)
+	}
+}
+
+func TestGenerateMnemonicsForDerivation(t *testing.T) {
+	specs := []string{"master", "index:0", "index:1", "name:0", "name:1", "name:validator-1"}
+	seen := map[string]string{}
+	for _, spec := range specs {
+		d, err := ParseDerivation(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, err := GenerateMnemonicsForDerivation(derivationMaster, d)
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+		// every mnemonic of a set must be new, not only the validator key
+		for _, m := range [][]byte{set.ValidatorAddrMnemonic, set.ValidatorValMnemonic, set.SignerAddrMnemonic, set.ValidatorNodeMnemonic} {
+			if prev, ok := seen[string(m)]; ok {
+				t.Errorf("%s and %s share a mnemonic", prev, spec)
+			}
+			seen[string(m)] = spec
+		}
+
+		again, err := GenerateMnemonicsForDerivation(derivationMaster, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again.ValidatorValMnemonic) != string(set.ValidatorValMnemonic) || string(again.ValidatorNodeId) != string(set.ValidatorNodeId) {
+			t.Errorf("%s: derivation is not deterministic", spec)
+		}
+	}
+
+	// the master scheme with default prefix and path is the plain master set
+	plain, err := GenerateMnemonicsFromMaster(derivationMaster, vlg.DefaultPrefix, vlg.DefaultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := GenerateMnemonicsForDerivation(derivationMaster, Derivation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(set.ValidatorValMnemonic) != string(plain.ValidatorValMnemonic) {
+		t.Error("zero derivation differs from the master set")
+	}
+}
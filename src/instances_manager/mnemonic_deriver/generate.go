@@ -0,0 +1,156 @@
+package mnemonicderiver
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/cosmos/go-bip39"
+)
+
+// User entropy formats accepted by ParseUserEntropy.
+const (
+	ENTROPY_DICE string = "dice"
+	ENTROPY_HEX  string = "hex"
+)
+
+// UserEntropy is extra entropy supplied by the operator, mixed into the
+// system randomness so that neither source alone decides the mnemonic.
+type UserEntropy struct {
+	Data []byte
+	// Bits is an estimate of the entropy in Data, assuming fair dice or
+	// uniformly random hex.
+	Bits float64
+}
+
+// Generated is a freshly generated master mnemonic.
+type Generated struct {
+	Mnemonic string
+	// Fingerprint is the first 8 bytes of sha256 over the mnemonic entropy; it
+	// can be recomputed offline from the mnemonic with EntropyFingerprint. It
+	// identifies the mnemonic, it says nothing about how the entropy was made.
+	Fingerprint string
+	// SystemEntropy is the crypto/rand input of MixEntropy. Together with the
+	// user entropy it lets the mix be recomputed, so it is as secret as the mnemonic.
+	SystemEntropy []byte
+	// UserEntropyDigest is the sha256 of the user entropy, empty if none was mixed in.
+	UserEntropyDigest string
+}
+
+// EntropyBits returns the BIP39 entropy size for a word count; only 12 and 24
+// words are supported.
+func EntropyBits(words int) (int, error) {
+	switch words {
+	case 12:
+		return 128, nil
+	case 24:
+		return 256, nil
+	}
+	return 0, fmt.Errorf("unsupported word count %d (use 12 or 24)", words)
+}
+
+// ParseUserEntropy reads dice rolls (digits 1-6, anything else but whitespace
+// is rejected) or a hex string.
+func ParseUserEntropy(format, s string) (*UserEntropy, error) {
+	s = strings.Join(strings.Fields(s), "")
+	if s == "" {
+		return nil, fmt.Errorf("user entropy is empty")
+	}
+	switch format {
+	case ENTROPY_DICE:
+		for i, r := range s {
+			if r < '1' || r > '6' {
+				return nil, fmt.Errorf("invalid dice roll %q at position %d (use 1-6)", r, i+1)
+			}
+		}
+		return &UserEntropy{Data: []byte(s), Bits: float64(len(s)) * math.Log2(6)}, nil
+	case ENTROPY_HEX:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex entropy: %w", err)
+		}
+		return &UserEntropy{Data: b, Bits: float64(8 * len(b))}, nil
+	}
+	return nil, fmt.Errorf("unknown entropy format %q (use %s or %s)", format, ENTROPY_DICE, ENTROPY_HEX)
+}
+
+// MixEntropy returns the mnemonic entropy of bits size for the given system
+// randomness and user entropy data: system truncated when user is empty,
+// sha256(system || user) truncated otherwise, so it stays unpredictable as long
+// as either source is.
+func MixEntropy(system, user []byte, bits int) []byte {
+	if len(user) == 0 {
+		return system[:bits/8]
+	}
+	h := sha256.New()
+	h.Write(system)
+	h.Write(user)
+	return h.Sum(nil)[:bits/8]
+}
+
+// GenerateMnemonic creates a BIP39 mnemonic of the given word count from
+// crypto/rand, mixed with the user entropy by MixEntropy. The result is checked
+// with CheckMnemonic before it is returned.
+func GenerateMnemonic(words int, user *UserEntropy) (*Generated, error) {
+	bits, err := EntropyBits(words)
+	if err != nil {
+		return nil, err
+	}
+	g := &Generated{SystemEntropy: make([]byte, sha256.Size)}
+	if _, err := rand.Read(g.SystemEntropy); err != nil {
+		return nil, fmt.Errorf("unable to read system randomness: %w", err)
+	}
+
+	var userData []byte
+	if user != nil {
+		userData = user.Data
+		digest := sha256.Sum256(user.Data)
+		g.UserEntropyDigest = hex.EncodeToString(digest[:])
+	}
+
+	g.Mnemonic, err = bip39.NewMnemonic(MixEntropy(g.SystemEntropy, userData, bits))
+	if err != nil {
+		return nil, err
+	}
+	if valid, _ := CheckMnemonic(g.Mnemonic); !valid {
+		return nil, fmt.Errorf("generated mnemonic failed validation")
+	}
+	g.Fingerprint, err = EntropyFingerprint(g.Mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// EntropyFingerprint returns the first 8 bytes (hex) of sha256 over the
+// entropy encoded by mnemonic.
+func EntropyFingerprint(mnemonic string) (string, error) {
+	entropy, err := mnemonicEntropy(mnemonic)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(entropy)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// mnemonicEntropy returns the BIP39 entropy of a valid mnemonic. The words
+// encode entropy || checksum as one big-endian number of len(words)*11 bits,
+// so the len(words)*11/33 checksum bits are shifted out and the rest is
+// left-padded to the entropy size.
+func mnemonicEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if valid, invalidWords := CheckMnemonic(mnemonic); !valid {
+		return nil, fmt.Errorf("invalid mnemonic, invalid words: %v", invalidWords)
+	}
+	n := new(big.Int)
+	for _, w := range words {
+		n.Lsh(n, 11)
+		n.Or(n, big.NewInt(int64(bip39.ReverseWordMap[w])))
+	}
+	n.Rsh(n, uint(len(words)*11/33))
+	return n.FillBytes(make([]byte, len(words)*4/3)), nil
+}
@@ -0,0 +1,128 @@
+package mnemonicderiver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// BIP39 test vectors with a fixed 16-byte and a fixed 32-byte entropy.
+var entropyVectors = []struct {
+	entropy  string
+	mnemonic string
+}{
+	{
+		entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+	},
+	{
+		entropy:  "8080808080808080808080808080808080808080808080808080808080808080",
+		mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless",
+	},
+	{
+		entropy:  "ffffffffffffffffffffffffffffffff",
+		mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+	},
+}
+
+func TestMnemonicEntropy(t *testing.T) {
+	for _, v := range entropyVectors {
+		want, _ := hex.DecodeString(v.entropy)
+		got, err := mnemonicEntropy(v.mnemonic)
+		if err != nil {
+			t.Fatalf("%s: %v", v.entropy, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("entropy of %q = %x, want %s", v.mnemonic, got, v.entropy)
+		}
+	}
+}
+
+func TestEntropyFingerprint(t *testing.T) {
+	for _, v := range entropyVectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		sum := sha256.Sum256(entropy)
+		want := hex.EncodeToString(sum[:8])
+
+		got, err := EntropyFingerprint(v.mnemonic)
+		if err != nil {
+			t.Fatalf("%s: %v", v.entropy, err)
+		}
+		if got != want {
+			t.Errorf("fingerprint of %s = %s, want %s", v.entropy, got, want)
+		}
+	}
+}
+
+func TestGenerateMnemonicFingerprint(t *testing.T) {
+	user, err := ParseUserEntropy(ENTROPY_DICE, "1234 5612 3456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, words := range []int{12, 24} {
+		g, err := GenerateMnemonic(words, user)
+		if err != nil {
+			t.Fatalf("%d words: %v", words, err)
+		}
+		want, err := EntropyFingerprint(g.Mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Fingerprint != want {
+			t.Errorf("%d words: fingerprint %s, recomputed %s", words, g.Fingerprint, want)
+		}
+	}
+}
+
+func TestMixEntropy(t *testing.T) {
+	system := make([]byte, 32)
+	for i := range system {
+		system[i] = byte(i)
+	}
+	// sha256(00 01 .. 1f || "123456")
+	mixed := "b274cdaf9d807a8dcd345a813cf92c7b86917d4bdfd2f9d99831b2560c486f7f"
+	tests := []struct {
+		user []byte
+		bits int
+		want string
+	}{
+		{user: []byte("123456"), bits: 128, want: mixed[:32]},
+		{user: []byte("123456"), bits: 256, want: mixed},
+		{bits: 128, want: hex.EncodeToString(system[:16])},
+		{bits: 256, want: hex.EncodeToString(system)},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(MixEntropy(system, tt.user, tt.bits)); got != tt.want {
+			t.Errorf("user %q, %d bits: got %s, want %s", tt.user, tt.bits, got, tt.want)
+		}
+	}
+}
+
+// The reported system entropy and the user entropy are enough to recompute the mnemonic.
+func TestGenerateMnemonicMixCanBeRecomputed(t *testing.T) {
+	user, err := ParseUserEntropy(ENTROPY_HEX, "deadbeef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []*UserEntropy{nil, user} {
+		for _, words := range []int{12, 24} {
+			g, err := GenerateMnemonic(words, u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data []byte
+			if u != nil {
+				data = u.Data
+			}
+			bits, _ := EntropyBits(words)
+			got, err := mnemonicEntropy(g.Mnemonic)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := MixEntropy(g.SystemEntropy, data, bits); !bytes.Equal(got, want) {
+				t.Errorf("%d words, user %x: mnemonic entropy %x, recomputed %x", words, data, got, want)
+			}
+		}
+	}
+}
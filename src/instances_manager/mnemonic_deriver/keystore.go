@@ -0,0 +1,148 @@
+package mnemonicderiver
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/crypt"
+)
+
+const (
+	// KEYSTORE_FILE_NAME holds the derived mnemonic set sealed with a passphrase.
+	KEYSTORE_FILE_NAME string = "masterSet.enc"
+	// PLAINTEXT_SET_FILE_NAME is only written on explicit request.
+	PLAINTEXT_SET_FILE_NAME string = "masterSet.txt"
+)
+
+var errNoPassphrase = errors.New("a passphrase is required for the keystore (or request plaintext output explicitly)")
+
+// KeySet is the derived mnemonic set as stored in the keystore.
+type KeySet struct {
+	ValAddrMnemonic    string
+	ValValMnemonic     string
+	SignerAddrMnemonic string
+	ValNodeMnemonic    string
+	ValNodeID          string
+}
+
+// keySetFields is the key=value order of the encoded set; it is the format the
+// plaintext masterSet.txt always had.
+var keySetFields = []string{"valAddrMnemonic", "valValMnemonic", "signerAddrMnemonic", "valNodeMnemonic", "valNodeID"}
+
+// NewKeySet takes the mnemonics of a set derived by GenerateMnemonicsFromMaster.
+func NewKeySet(set *vlg.MasterMnemonicSet) *KeySet {
+	return &KeySet{
+		ValAddrMnemonic:    string(set.ValidatorAddrMnemonic),
+		ValValMnemonic:     string(set.ValidatorValMnemonic),
+		SignerAddrMnemonic: string(set.SignerAddrMnemonic),
+		ValNodeMnemonic:    string(set.ValidatorNodeMnemonic),
+		ValNodeID:          string(set.ValidatorNodeId),
+	}
+}
+
+func (k *KeySet) values() []*string {
+	return []*string{&k.ValAddrMnemonic, &k.ValValMnemonic, &k.SignerAddrMnemonic, &k.ValNodeMnemonic, &k.ValNodeID}
+}
+
+// Encode renders the set as key=value lines.
+func (k *KeySet) Encode() []byte {
+	var b bytes.Buffer
+	for i, v := range k.values() {
+		fmt.Fprintf(&b, "%s=%s\n", keySetFields[i], *v)
+	}
+	return b.Bytes()
+}
+
+// ParseKeySet reverses Encode; every field must be present.
+func ParseKeySet(data []byte) (*KeySet, error) {
+	k := &KeySet{}
+	found := map[string]bool{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), "=")
+		if !ok {
+			continue
+		}
+		for i, name := range keySetFields {
+			if key == name {
+				*k.values()[i] = value
+				found[name] = true
+			}
+		}
+	}
+	for _, name := range keySetFields {
+		if !found[name] {
+			return nil, fmt.Errorf("key set has no %s", name)
+		}
+	}
+	return k, nil
+}
+
+// WriteKeystore seals the set with passphrase into path.
+func WriteKeystore(path string, set *KeySet, passphrase []byte) error {
+	sealed, err := crypt.Seal(set.Encode(), passphrase)
+	if err != nil {
+		return err
+	}
+	return writeSecret(path, sealed)
+}
+
+// ReadKeystore opens a keystore written by WriteKeystore.
+func ReadKeystore(path string, passphrase []byte) (*KeySet, error) {
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !crypt.IsSealed(sealed) {
+		return nil, fmt.Errorf("%s is not an encrypted keystore", path)
+	}
+	plain, err := crypt.Open(sealed, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeySet(plain)
+}
+
+// WritePlaintextSet writes the set unencrypted, readable by the owner only.
+func WritePlaintextSet(path string, set *KeySet) error {
+	return writeSecret(path, set.Encode())
+}
+
+// writeSecret writes data with 0600 through a temporary file, so a failed write
+// never leaves a truncated secret behind, and reports every failing step.
+func writeSecret(path string, data []byte) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("rename %s: %w", path, err)
+	}
+	return nil
+}
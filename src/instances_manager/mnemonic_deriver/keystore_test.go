@@ -0,0 +1,189 @@
+package mnemonicderiver
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/crypt"
+)
+
+const keystoreMaster = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+
+var testKeySet = &KeySet{
+	ValAddrMnemonic:    "val addr words",
+	ValValMnemonic:     "val val words",
+	SignerAddrMnemonic: "signer addr words",
+	ValNodeMnemonic:    "val node words",
+	ValNodeID:          "0123456789abcdef0123456789abcdef01234567",
+}
+
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mode().Perm() != want {
+		t.Errorf("%s has mode %v, want %v", path, st.Mode().Perm(), want)
+	}
+}
+
+func TestKeySetEncode(t *testing.T) {
+	enc := testKeySet.Encode()
+	if !bytes.HasPrefix(enc, []byte("valAddrMnemonic=val addr words\n")) {
+		t.Errorf("encoded set:\n%s", enc)
+	}
+	got, err := ParseKeySet(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *testKeySet {
+		t.Errorf("parsed %+v, want %+v", got, testKeySet)
+	}
+
+	partial := bytes.Replace(enc, []byte("valNodeID="), []byte("# valNodeID="), 1)
+	if _, err := ParseKeySet(partial); err == nil || !strings.Contains(err.Error(), "valNodeID") {
+		t.Errorf("set without valNodeID: %v", err)
+	}
+}
+
+func TestKeystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), KEYSTORE_FILE_NAME)
+	pass := []byte("passphrase")
+	if err := WriteKeystore(path, testKeySet, pass); err != nil {
+		t.Fatal(err)
+	}
+	assertMode(t, path, 0o600)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte(testKeySet.ValValMnemonic)) {
+		t.Fatal("keystore contains a mnemonic in cleartext")
+	}
+
+	got, err := ReadKeystore(path, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *testKeySet {
+		t.Errorf("read %+v, want %+v", got, testKeySet)
+	}
+	if _, err := ReadKeystore(path, []byte("Passphrase")); !errors.Is(err, crypt.ErrDecrypt) {
+		t.Errorf("wrong passphrase: error = %v, want ErrDecrypt", err)
+	}
+	if err := WriteKeystore(path, testKeySet, nil); err == nil {
+		t.Error("keystore written without a passphrase")
+	}
+
+	plain := filepath.Join(t.TempDir(), PLAINTEXT_SET_FILE_NAME)
+	if err := WritePlaintextSet(plain, testKeySet); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadKeystore(plain, pass); err == nil || !strings.Contains(err.Error(), "not an encrypted keystore") {
+		t.Errorf("plaintext set read as a keystore: %v", err)
+	}
+}
+
+func TestWriteSecretReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, PLAINTEXT_SET_FILE_NAME)
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSecret(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	assertMode(t, path, 0o600)
+	if b, _ := os.ReadFile(path); string(b) != "new" {
+		t.Errorf("content %q", b)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in the folder, a temporary file was left behind", len(entries))
+	}
+
+	if err := writeSecret(filepath.Join(dir, "missing", "secret"), []byte("x")); err == nil {
+		t.Error("wrote into a folder that does not exist")
+	}
+}
+
+func TestDeliverMnemonicKeysFromMaster(t *testing.T) {
+	set, err := GenerateMnemonicsFromMaster(keystoreMaster, vlg.DefaultPrefix, vlg.DefaultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewKeySet(set)
+	pass := []byte("passphrase")
+
+	t.Run("passphrase required", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out")
+		if _, err := DeliverMnemonicKeysFromMaster(keystoreMaster, vlg.DefaultPrefix, vlg.DefaultPath, out, DeliverOptions{}); !errors.Is(err, errNoPassphrase) {
+			t.Fatalf("error = %v, want errNoPassphrase", err)
+		}
+		if _, err := os.Stat(out); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("output folder created: %v", err)
+		}
+	})
+
+	t.Run("keystore", func(t *testing.T) {
+		out := t.TempDir()
+		setFile, err := DeliverMnemonicKeysFromMaster(keystoreMaster, vlg.DefaultPrefix, vlg.DefaultPath, out, DeliverOptions{Passphrase: pass})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if setFile != filepath.Join(out, KEYSTORE_FILE_NAME) {
+			t.Errorf("set written to %s", setFile)
+		}
+		got, err := ReadKeystore(setFile, pass)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("keystore holds %+v, want %+v", got, want)
+		}
+		if _, err := os.Stat(filepath.Join(out, PLAINTEXT_SET_FILE_NAME)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("plaintext set written next to the keystore: %v", err)
+		}
+		for _, name := range []string{"priv_validator_key.json", "node_key.json"} {
+			if _, err := os.Stat(filepath.Join(out, "config", name)); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("insecure plaintext", func(t *testing.T) {
+		out := t.TempDir()
+		setFile, err := DeliverMnemonicKeysFromMaster(keystoreMaster, vlg.DefaultPrefix, vlg.DefaultPath, out, DeliverOptions{InsecurePlaintext: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if setFile != filepath.Join(out, PLAINTEXT_SET_FILE_NAME) {
+			t.Errorf("set written to %s", setFile)
+		}
+		assertMode(t, setFile, 0o600)
+		b, err := os.ReadFile(setFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseKeySet(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("plaintext set holds %+v, want %+v", got, want)
+		}
+		if _, err := os.Stat(filepath.Join(out, KEYSTORE_FILE_NAME)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("keystore written next to the plaintext set: %v", err)
+		}
+	})
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	vlg "github.com/KiraCore/tools/validator-key-gen/MnemonicsGenerator"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
 	"github.com/cosmos/go-bip39"
 )
 
@@ -54,42 +56,76 @@ func SetSekaidPrivKeys(mnemonicSet *vlg.MasterMnemonicSet, homeFolder string) er
 	return nil
 }
 
-func DeliverMnemonicKeysFromMaster(masterMnemonic, prefix, path, outFolder string) error {
+// AccAddressFromMnemonic returns the bech32 account address of the secp256k1 key
+// derived from mnemonic at the given HD path, e.g. kira1... for prefix "kira".
+func AccAddressFromMnemonic(mnemonic, prefix, path string) (string, error) {
+	derived, err := hd.Secp256k1.Derive()(mnemonic, "", path)
+	if err != nil {
+		return "", fmt.Errorf("unable to derive key: %w", err)
+	}
+	privKey := hd.Secp256k1.Generate()(derived)
+	return bech32.ConvertAndEncode(prefix, privKey.PubKey().Address().Bytes())
+}
+
+// DeliverOptions controls how DeliverMnemonicKeysFromMaster stores the derived set.
+type DeliverOptions struct {
+	// Passphrase seals the set into KEYSTORE_FILE_NAME.
+	Passphrase []byte
+	// InsecurePlaintext writes PLAINTEXT_SET_FILE_NAME in cleartext instead.
+	InsecurePlaintext bool
+	// Derivation selects the set; its Prefix and Path are taken from the
+	// arguments. The zero value is the master's own set.
+	Derivation Derivation
+}
+
+// DeliverMnemonicKeysFromMaster writes the validator and node keys derived from the
+// master into outFolder/config, and the derived mnemonic set into an encrypted
+// keystore (or, only when asked for, a plaintext file). It returns the set file path.
+func DeliverMnemonicKeysFromMaster(masterMnemonic, prefix, path, outFolder string, opts DeliverOptions) (string, error) {
 	valid, invalidWords := CheckMnemonic(masterMnemonic)
 	if !valid {
-		return fmt.Errorf("invalid mnemonic, invalid words: %v", invalidWords)
+		return "", fmt.Errorf("invalid mnemonic, invalid words: %v", invalidWords)
 	}
-	set, err := GenerateMnemonicsFromMaster(masterMnemonic, prefix, path)
-	if err != nil {
-		return err
+	if !opts.InsecurePlaintext && len(opts.Passphrase) == 0 {
+		return "", errNoPassphrase
 	}
-	err = os.MkdirAll(outFolder, 0755)
+	d := opts.Derivation
+	d.Prefix, d.Path = prefix, path
+	set, err := GenerateMnemonicsForDerivation(masterMnemonic, d)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if err := os.MkdirAll(outFolder, 0755); err != nil {
+		return "", err
 	}
+	if err := SetSekaidPrivKeys(set, outFolder); err != nil {
+		return "", err
+	}
+
+	keys := NewKeySet(set)
+	if opts.InsecurePlaintext {
+		setFile := filepath.Join(outFolder, PLAINTEXT_SET_FILE_NAME)
+		if err := WritePlaintextSet(setFile, keys); err != nil {
+			return "", fmt.Errorf("unable to write %s: %w", setFile, err)
+		}
+		return setFile, nil
+	}
+	setFile := filepath.Join(outFolder, KEYSTORE_FILE_NAME)
+	if err := WriteKeystore(setFile, keys, opts.Passphrase); err != nil {
+		return "", fmt.Errorf("unable to write %s: %w", setFile, err)
+	}
+	return setFile, nil
+}
 
-	err = SetSekaidPrivKeys(set, outFolder)
+// DeliverMnemonicKeysFromShares combines a quorum of master shares in memory and
+// delivers the keys as DeliverMnemonicKeysFromMaster does; the master itself is
+// never written out.
+func DeliverMnemonicKeysFromShares(shares []string, prefix, path, outFolder string, opts DeliverOptions) (string, error) {
+	masterMnemonic, err := CombineMnemonicShares(shares)
 	if err != nil {
-		return err
-	}
-
-	// 	type MasterMnemonicSet struct {
-	//     ValidatorAddrMnemonic []byte
-	//     ValidatorValMnemonic  []byte
-	//     SignerAddrMnemonic    []byte
-	//     ValidatorNodeMnemonic []byte
-	//     ValidatorNodeId       []byte
-	//     PrivKeyMnemonic       []byte
-
-	stringset := fmt.Sprintf(`
-valAddrMnemonic=%s
-valValMnemonic=%s
-signerAddrMnemonic=%s
-valNodeMnemonic=%s
-valNodeID=%s`, (set.ValidatorAddrMnemonic), set.ValidatorValMnemonic, set.SignerAddrMnemonic, set.ValidatorNodeMnemonic, set.ValidatorNodeId)
-	setFile := filepath.Join(outFolder, "masterSet.txt")
-	err = os.WriteFile(setFile, []byte(stringset), 0600)
-	return nil
+		return "", err
+	}
+	return DeliverMnemonicKeysFromMaster(masterMnemonic, prefix, path, outFolder, opts)
 }
 
 // CheckMnemonic prints invalid words (not in BIP39 wordlist) and returns whether the mnemonic is valid.
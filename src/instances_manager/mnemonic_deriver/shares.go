@@ -0,0 +1,195 @@
+package mnemonicderiver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/shamir"
+	"github.com/cosmos/go-bip39"
+)
+
+// A share is written as BIP39 words (11 bits each) encoding
+//
+//	version | identifier (2) | threshold | index | length | value | checksum (4)
+//
+// value is one Shamir share of entropy || sha256(entropy)[:4] of the master
+// mnemonic, so a combined secret can be verified without the digest leaking
+// from a single share. checksum is sha256 over the preceding bytes and catches
+// mistyped words.
+const (
+	shareVersion      byte = 1
+	shareHeaderSize        = 6
+	shareChecksumSize      = 4
+	secretDigestSize       = 4
+)
+
+var ErrShareChecksum = errors.New("share checksum mismatch (mistyped or missing word?)")
+
+// MnemonicShare is a decoded share.
+type MnemonicShare struct {
+	ID        uint16
+	Threshold int
+	shamir.Share
+}
+
+// SplitMnemonic splits the master mnemonic into n word shares, any threshold of
+// which rebuild it with CombineMnemonicShares.
+func SplitMnemonic(masterMnemonic string, n, threshold int) ([]string, error) {
+	entropy, err := mnemonicEntropy(masterMnemonic)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(entropy)
+	secret := append(append([]byte(nil), entropy...), digest[:secretDigestSize]...)
+
+	parts, err := shamir.Split(secret, n, threshold)
+	if err != nil {
+		return nil, err
+	}
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	shares := make([]string, len(parts))
+	for i, p := range parts {
+		b := []byte{shareVersion, id[0], id[1], byte(threshold), p.Index, byte(len(p.Value))}
+		b = append(b, p.Value...)
+		sum := sha256.Sum256(b)
+		shares[i] = encodeWords(append(b, sum[:shareChecksumSize]...))
+	}
+	return shares, nil
+}
+
+// ParseMnemonicShare decodes and checks one word share.
+func ParseMnemonicShare(share string) (*MnemonicShare, error) {
+	words := strings.Fields(strings.ToLower(share))
+	b, err := decodeWords(words)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < shareHeaderSize {
+		return nil, errors.New("share is too short")
+	}
+	if b[0] != shareVersion {
+		return nil, fmt.Errorf("unsupported share version %d", b[0])
+	}
+	size := shareHeaderSize + int(b[5]) + shareChecksumSize
+	if len(b) < size || (size*8+10)/11 != len(words) {
+		return nil, fmt.Errorf("share has %d words, its header expects %d", len(words), (size*8+10)/11)
+	}
+	for _, c := range b[size:] {
+		if c != 0 {
+			return nil, ErrShareChecksum
+		}
+	}
+	body := b[:size-shareChecksumSize]
+	sum := sha256.Sum256(body)
+	if !bytes.Equal(sum[:shareChecksumSize], b[size-shareChecksumSize:size]) {
+		return nil, ErrShareChecksum
+	}
+	s := &MnemonicShare{
+		ID:        uint16(b[1])<<8 | uint16(b[2]),
+		Threshold: int(b[3]),
+		Share:     shamir.Share{Index: b[4], Value: append([]byte(nil), body[shareHeaderSize:]...)},
+	}
+	if s.Index == 0 || s.Threshold < shamir.MIN_THRESHOLD {
+		return nil, errors.New("share header is invalid")
+	}
+	return s, nil
+}
+
+// CombineMnemonicShares rebuilds the master mnemonic from at least threshold
+// shares of the same split and verifies it against the embedded digest.
+func CombineMnemonicShares(shares []string) (string, error) {
+	if len(shares) == 0 {
+		return "", errors.New("no shares given")
+	}
+	parts := make([]shamir.Share, 0, len(shares))
+	var first *MnemonicShare
+	for i, raw := range shares {
+		s, err := ParseMnemonicShare(raw)
+		if err != nil {
+			return "", fmt.Errorf("share %d: %w", i+1, err)
+		}
+		if first == nil {
+			first = s
+		} else if s.ID != first.ID || s.Threshold != first.Threshold {
+			return "", fmt.Errorf("share %d belongs to a different split", i+1)
+		}
+		parts = append(parts, s.Share)
+	}
+	if len(parts) < first.Threshold {
+		return "", fmt.Errorf("%d of %d required shares given", len(parts), first.Threshold)
+	}
+
+	secret, err := shamir.Combine(parts)
+	if err != nil {
+		return "", err
+	}
+	if len(secret) <= secretDigestSize {
+		return "", errors.New("combined secret is too short")
+	}
+	entropy, digest := secret[:len(secret)-secretDigestSize], secret[len(secret)-secretDigestSize:]
+	want := sha256.Sum256(entropy)
+	if !bytes.Equal(want[:secretDigestSize], digest) {
+		return "", errors.New("combined secret failed verification (shares do not fit together)")
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", err
+	}
+	if valid, _ := CheckMnemonic(mnemonic); !valid {
+		return "", errors.New("combined mnemonic failed validation")
+	}
+	return mnemonic, nil
+}
+
+// WriteShare writes one share to path, readable by the owner only.
+func WriteShare(path, share string) error {
+	return writeSecret(path, []byte(share+"\n"))
+}
+
+// encodeWords writes b as 11-bit BIP39 word indexes, zero padded.
+func encodeWords(b []byte) string {
+	var words []string
+	var acc, bits uint
+	for _, c := range b {
+		acc = acc<<8 | uint(c)
+		bits += 8
+		for bits >= 11 {
+			bits -= 11
+			words = append(words, bip39.WordList[(acc>>bits)&0x7ff])
+		}
+	}
+	if bits > 0 {
+		words = append(words, bip39.WordList[(acc<<(11-bits))&0x7ff])
+	}
+	return strings.Join(words, " ")
+}
+
+// decodeWords reverses encodeWords; trailing padding bits become zero bytes
+// only when a whole byte fits into them.
+func decodeWords(words []string) ([]byte, error) {
+	var out []byte
+	var acc, bits uint
+	for _, w := range words {
+		idx, ok := bip39.ReverseWordMap[w]
+		if !ok {
+			return nil, fmt.Errorf("%q is not a share word", w)
+		}
+		acc = acc<<11 | uint(idx)
+		bits += 11
+		for bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+	}
+	if acc&(1<<bits-1) != 0 {
+		return nil, ErrShareChecksum
+	}
+	return out, nil
+}
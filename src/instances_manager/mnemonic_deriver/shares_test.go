@@ -0,0 +1,63 @@
+package mnemonicderiver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSplitCombineRoundTrip(t *testing.T) {
+	for _, v := range entropyVectors {
+		shares, err := SplitMnemonic(v.mnemonic, 5, 3)
+		if err != nil {
+			t.Fatalf("%s: %v", v.entropy, err)
+		}
+		for _, subset := range [][]int{{0, 1, 2}, {0, 2, 4}, {1, 3, 4}, {2, 3, 4}, {0, 1, 2, 3, 4}} {
+			var picked []string
+			for _, i := range subset {
+				picked = append(picked, shares[i])
+			}
+			got, err := CombineMnemonicShares(picked)
+			if err != nil {
+				t.Fatalf("%s shares %v: %v", v.entropy, subset, err)
+			}
+			if got != v.mnemonic {
+				t.Fatalf("%s shares %v: combined to %q, want %q", v.entropy, subset, got, v.mnemonic)
+			}
+		}
+		if _, err := CombineMnemonicShares(shares[:2]); err == nil {
+			t.Errorf("%s: 2 of 3 shares combined", v.entropy)
+		}
+	}
+}
+
+func TestCombineRejectsBadShares(t *testing.T) {
+	mnemonic := entropyVectors[0].mnemonic
+	a, err := SplitMnemonic(mnemonic, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SplitMnemonic(mnemonic, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CombineMnemonicShares([]string{a[0], b[1]}); err == nil {
+		t.Error("shares of different splits combined")
+	}
+
+	words := strings.Fields(a[1])
+	if words[10] == "abandon" {
+		words[10] = "ability"
+	} else {
+		words[10] = "abandon"
+	}
+	_, err = CombineMnemonicShares([]string{a[0], strings.Join(words, " ")})
+	if !errors.Is(err, ErrShareChecksum) {
+		t.Errorf("mistyped share: got %v, want %v", err, ErrShareChecksum)
+	}
+
+	if _, err := CombineMnemonicShares([]string{a[0], strings.Join(strings.Fields(a[1])[1:], " ")}); err == nil {
+		t.Error("share with a missing word combined")
+	}
+}
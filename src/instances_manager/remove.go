@@ -0,0 +1,162 @@
+package instancesmanager
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/PeepoFrog/sekai_manager/src/cfg"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/crypt"
+	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/supervisor"
+	tmrpc "github.com/PeepoFrog/sekai_manager/src/instances_manager/tm_rpc"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+const ARCHIVE_FOLDER_NAME string = "archive"
+
+// archivedKeyFiles are taken from the instance home before it is deleted.
+var archivedKeyFiles = []string{
+	filepath.Join("config", "priv_validator_key.json"),
+	filepath.Join("config", "node_key.json"),
+	mnemonicderiver.KEYSTORE_FILE_NAME,
+	mnemonicderiver.PLAINTEXT_SET_FILE_NAME,
+}
+
+var ErrActiveValidator = errors.New("instance is running as an active validator")
+
+// RemoveOptions controls RemoveInstance.
+type RemoveOptions struct {
+	// Passphrase encrypts the key archive. Required.
+	Passphrase []byte
+	// Force skips the active validator check.
+	Force       bool
+	StopTimeout time.Duration
+}
+
+// RemoveInstance stops the instance, archives its keys into an encrypted bundle
+// under <manager home>/archive, deletes its home and unregisters it.
+// It returns the archive path ("" when the instance had no key files).
+func (im *InstanceManager) RemoveInstance(ctx context.Context, name string, opts RemoveOptions) (string, error) {
+	if len(opts.Passphrase) == 0 {
+		return "", errors.New("passphrase for the key archive is empty")
+	}
+	if opts.StopTimeout == 0 {
+		opts.StopTimeout = supervisor.DefaultStopTimeout
+	}
+	ic, err := im.GetInstance(name)
+	if err != nil {
+		return "", err
+	}
+
+	sv := supervisor.New(im.Home)
+	state, _, err := sv.State(name)
+	if err != nil {
+		return "", err
+	}
+	if state == supervisor.StateRunning && !opts.Force {
+		power, err := votingPower(ctx, ic)
+		if err != nil {
+			return "", fmt.Errorf("unable to check validator status (use --force to skip): %w", err)
+		}
+		if power > 0 {
+			return "", fmt.Errorf("%w (voting power %d), use --force to remove anyway", ErrActiveValidator, power)
+		}
+	}
+	if err := sv.Stop(name, opts.StopTimeout); err != nil && !errors.Is(err, supervisor.ErrNotRunning) {
+		return "", err
+	}
+
+	archive, err := im.archiveKeys(ic, opts.Passphrase)
+	if err != nil {
+		return "", fmt.Errorf("unable to archive keys, nothing was deleted: %w", err)
+	}
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	if _, err := im.unregister(name); err != nil {
+		return archive, err
+	}
+	if err := os.RemoveAll(ic.Home); err != nil {
+		return archive, fmt.Errorf("instance unregistered but its home could not be deleted: %w", err)
+	}
+	return archive, nil
+}
+
+func votingPower(ctx context.Context, ic types.InstanceConfig) (int64, error) {
+	ab, err := cfg.BindingOf(ic)
+	if err != nil {
+		return 0, err
+	}
+	client, err := tmrpc.NewClient(ab.RpcLaddr, &http.Client{Timeout: DefaultStatusTimeout})
+	if err != nil {
+		return 0, err
+	}
+	status, err := client.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return status.ValidatorInfo.VotingPower, nil
+}
+
+// archiveKeys writes the key files of the instance into an encrypted tar.gz.
+func (im *InstanceManager) archiveKeys(ic types.InstanceConfig, passphrase []byte) (string, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	added := 0
+	for _, rel := range archivedKeyFiles {
+		b, err := os.ReadFile(filepath.Join(ic.Home, rel))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		hdr := &tar.Header{
+			Name:    filepath.ToSlash(rel),
+			Mode:    0o600,
+			Size:    int64(len(b)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return "", err
+		}
+		if _, err := tw.Write(b); err != nil {
+			return "", err
+		}
+		added++
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	if added == 0 {
+		return "", nil
+	}
+
+	sealed, err := crypt.Seal(buf.Bytes(), passphrase)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(im.Home, ARCHIVE_FOLDER_NAME)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.tar.gz.enc", ic.Name, time.Now().UTC().Format("20060102T150405Z")))
+	if err := os.WriteFile(path, sealed, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,224 @@
+package instancesmanager
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/crypt"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/supervisor"
+)
+
+var testPassphrase = []byte("correct horse battery staple")
+
+// withKeys creates an instance holding the given files relative to its home.
+func withKeys(t *testing.T, im *InstanceManager, name string, files map[string]string) {
+	t.Helper()
+	if err := im.CreateInstance(name); err != nil {
+		t.Fatal(err)
+	}
+	for rel, body := range files {
+		path := filepath.Join(im.InstanceHome(name), rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readArchive decrypts a key archive and returns its files by name.
+func readArchive(t *testing.T, path string) map[string]string {
+	t.Helper()
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crypt.Open(sealed, []byte("wrong")); !errors.Is(err, crypt.ErrDecrypt) {
+		t.Errorf("archive opened with a wrong passphrase: %v", err)
+	}
+	plain, err := crypt.Open(sealed, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = string(b)
+	}
+	return files
+}
+
+func TestRemoveInstanceArchivesKeys(t *testing.T) {
+	im := testManager(t)
+	keys := map[string]string{
+		"config/priv_validator_key.json": `{"priv_key":"validator"}`,
+		"config/node_key.json":           `{"priv_key":"node"}`,
+	}
+	withKeys(t, im, "node", keys)
+	withKeys(t, im, "other", nil)
+	home := im.InstanceHome("node")
+
+	archive, err := im.RemoveInstance(context.Background(), "node", RemoveOptions{Passphrase: testPassphrase})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(archive) != filepath.Join(im.Home, ARCHIVE_FOLDER_NAME) || !strings.HasPrefix(filepath.Base(archive), "node-") {
+		t.Errorf("archive written to %s", archive)
+	}
+	if st, err := os.Stat(archive); err != nil || st.Mode().Perm() != 0o600 {
+		t.Errorf("archive mode: %v, %v", st, err)
+	}
+	got := readArchive(t, archive)
+	if len(got) != len(keys) {
+		t.Errorf("archive holds %d files, want %d", len(got), len(keys))
+	}
+	for name, body := range keys {
+		if got[name] != body {
+			t.Errorf("%s: archived %q, want %q", name, got[name], body)
+		}
+	}
+
+	if _, err := os.Stat(home); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("instance home not deleted: %v", err)
+	}
+	if _, err := im.GetInstance("node"); !errors.Is(err, ErrInstanceNotFound) {
+		t.Errorf("instance still registered: %v", err)
+	}
+	if _, err := im.GetInstance("other"); err != nil {
+		t.Errorf("other instance: %v", err)
+	}
+
+	// an instance without key files leaves no archive behind
+	archive, err = im.RemoveInstance(context.Background(), "other", RemoveOptions{Passphrase: testPassphrase})
+	if err != nil || archive != "" {
+		t.Errorf("archive %q, error %v for an instance without keys", archive, err)
+	}
+}
+
+func TestRemoveInstanceKeepsEverythingWhenArchivingFails(t *testing.T) {
+	im := testManager(t)
+	withKeys(t, im, "node", map[string]string{"config/node_key.json": `{"priv_key":"node"}`})
+
+	// the archive folder can not be created over a regular file
+	if err := os.WriteFile(filepath.Join(im.Home, ARCHIVE_FOLDER_NAME), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := im.RemoveInstance(context.Background(), "node", RemoveOptions{Passphrase: testPassphrase})
+	if err == nil || !strings.Contains(err.Error(), "nothing was deleted") {
+		t.Fatalf("error = %v, want an archive failure", err)
+	}
+	if _, err := os.Stat(filepath.Join(im.InstanceHome("node"), "config", "node_key.json")); err != nil {
+		t.Errorf("key file deleted: %v", err)
+	}
+	if _, err := im.GetInstance("node"); err != nil {
+		t.Errorf("instance unregistered: %v", err)
+	}
+}
+
+func TestRemoveInstanceRequiresPassphrase(t *testing.T) {
+	im := testManager(t)
+	withKeys(t, im, "node", nil)
+	if _, err := im.RemoveInstance(context.Background(), "node", RemoveOptions{}); err == nil {
+		t.Fatal("removed an instance without a passphrase for its keys")
+	}
+	if _, err := os.Stat(im.InstanceHome("node")); err != nil {
+		t.Errorf("instance home: %v", err)
+	}
+}
+
+// runningNode starts the first instance of im under a fake sekaid whose rpc
+// reports the given voting power.
+func runningNode(t *testing.T, im *InstanceManager, power int64) *supervisor.Supervisor {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sekaid is a shell script")
+	}
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":-1,"result":{"validator_info":{"address":"AB","voting_power":"%d"}}}`, power)
+	}))
+	t.Cleanup(rpc.Close)
+
+	bin := filepath.Join(t.TempDir(), "sekaid")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\ntrap 'exit 0' TERM\nwhile :; do sleep 0.1; done\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ic := &im.Instances[0]
+	ic.Binary = bin
+	ic.Addresses.RpcLaddr = strings.Replace(rpc.URL, "http://", "tcp://", 1)
+
+	sv := supervisor.New(im.Home)
+	sv.StartGrace = 200 * time.Millisecond
+	if _, err := sv.Start(*ic); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sv.Kill(ic.Name) })
+	return sv
+}
+
+func TestRemoveInstanceRefusesActiveValidator(t *testing.T) {
+	im := testManager(t)
+	withKeys(t, im, "node", map[string]string{"config/node_key.json": `{"priv_key":"node"}`})
+	sv := runningNode(t, im, 10)
+
+	opts := RemoveOptions{Passphrase: testPassphrase, StopTimeout: 5 * time.Second}
+	if _, err := im.RemoveInstance(context.Background(), "node", opts); !errors.Is(err, ErrActiveValidator) {
+		t.Fatalf("error = %v, want ErrActiveValidator", err)
+	}
+	if state, _, _ := sv.State("node"); state != supervisor.StateRunning {
+		t.Errorf("active validator left in state %q", state)
+	}
+	if _, err := os.Stat(im.InstanceHome("node")); err != nil {
+		t.Errorf("instance home: %v", err)
+	}
+
+	// --force skips the check
+	opts.Force = true
+	if _, err := im.RemoveInstance(context.Background(), "node", opts); err != nil {
+		t.Fatal(err)
+	}
+	if state, _, _ := sv.State("node"); state != supervisor.StateStopped {
+		t.Errorf("removed instance left in state %q", state)
+	}
+}
+
+func TestRemoveInstanceStopsInactiveNode(t *testing.T) {
+	im := testManager(t)
+	withKeys(t, im, "node", nil)
+	sv := runningNode(t, im, 0)
+
+	if _, err := im.RemoveInstance(context.Background(), "node", RemoveOptions{Passphrase: testPassphrase, StopTimeout: 5 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if state, _, _ := sv.State("node"); state != supervisor.StateStopped {
+		t.Errorf("removed instance left in state %q", state)
+	}
+}
@@ -0,0 +1,124 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+const (
+	// MAX_SHARES keeps share indexes in 1..MAX_SHARES; x=0 is where the secret lives.
+	MAX_SHARES int = 16
+	// MIN_THRESHOLD rules out 1-of-n splits, where every share is the secret itself.
+	MIN_THRESHOLD int = 2
+)
+
+// Share is one point of the secret polynomial, evaluated byte-wise in GF(256).
+type Share struct {
+	Index byte
+	Value []byte
+}
+
+// Split divides secret into n shares, any threshold of which recover it; fewer
+// reveal nothing about it.
+func Split(secret []byte, n, threshold int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+	if n < MIN_THRESHOLD || n > MAX_SHARES {
+		return nil, fmt.Errorf("share count %d out of range %d..%d", n, MIN_THRESHOLD, MAX_SHARES)
+	}
+	if threshold < MIN_THRESHOLD || threshold > n {
+		return nil, fmt.Errorf("threshold %d out of range %d..%d", threshold, MIN_THRESHOLD, n)
+	}
+
+	// coeffs[i] holds the coefficients of the polynomial for secret byte i,
+	// constant term first.
+	coeffs := make([][]byte, len(secret))
+	for i, b := range secret {
+		coeffs[i] = make([]byte, threshold)
+		coeffs[i][0] = b
+		if _, err := rand.Read(coeffs[i][1:]); err != nil {
+			return nil, err
+		}
+	}
+
+	shares := make([]Share, n)
+	for s := range shares {
+		x := byte(s + 1)
+		shares[s] = Share{Index: x, Value: make([]byte, len(secret))}
+		for i, c := range coeffs {
+			shares[s].Value[i] = eval(c, x)
+		}
+	}
+	return shares, nil
+}
+
+// Combine interpolates the shares at x=0. It cannot tell a wrong result from a
+// right one; callers verify the secret themselves.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares given")
+	}
+	size := len(shares[0].Value)
+	seen := map[byte]bool{}
+	for _, s := range shares {
+		if s.Index == 0 {
+			return nil, errors.New("share index 0 is invalid")
+		}
+		if seen[s.Index] {
+			return nil, fmt.Errorf("share %d given twice", s.Index)
+		}
+		seen[s.Index] = true
+		if len(s.Value) != size {
+			return nil, errors.New("shares have different lengths")
+		}
+	}
+
+	secret := make([]byte, size)
+	for i, s := range shares {
+		// Lagrange basis polynomial of share i at x=0.
+		basis := byte(1)
+		for j, o := range shares {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, div(o.Index, o.Index^s.Index))
+		}
+		for k := range secret {
+			secret[k] ^= mul(basis, s.Value[k])
+		}
+	}
+	return secret, nil
+}
+
+// eval evaluates the polynomial with the given coefficients at x (Horner).
+func eval(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coeffs[i]
+	}
+	return y
+}
+
+// mul multiplies in GF(256) with the AES polynomial x^8+x^4+x^3+x+1, without
+// data-dependent branches.
+func mul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		hi := -(a >> 7)
+		a = (a << 1) ^ (hi & 0x1b)
+		b >>= 1
+	}
+	return p
+}
+
+// div returns a/b for b != 0, using b^254 as the inverse.
+func div(a, b byte) byte {
+	inv := b
+	for i := 0; i < 6; i++ {
+		inv = mul(mul(inv, inv), b)
+	}
+	return mul(a, mul(inv, inv))
+}
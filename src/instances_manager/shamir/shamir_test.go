@@ -0,0 +1,143 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMul(t *testing.T) {
+	// FIPS-197 section 4.2 examples.
+	cases := []struct{ a, b, want byte }{
+		{0x57, 0x83, 0xc1},
+		{0x57, 0x13, 0xfe},
+		{0x57, 0x01, 0x57},
+		{0x00, 0xff, 0x00},
+	}
+	for _, c := range cases {
+		if got := mul(c.a, c.b); got != c.want {
+			t.Errorf("mul(%#x, %#x) = %#x, want %#x", c.a, c.b, got, c.want)
+		}
+	}
+	for b := 1; b < 256; b++ {
+		if got := mul(div(1, byte(b)), byte(b)); got != 1 {
+			t.Fatalf("inverse of %#x is wrong: %#x * b = %#x", b, div(1, byte(b)), got)
+		}
+	}
+}
+
+func TestCombineKnownAnswer(t *testing.T) {
+	// Per byte position the secret polynomials are
+	//   f(x) = 0x42 + 0x01*x + 0x02*x^2
+	//   g(x) = 0x07 + 0x10*x
+	// evaluated by hand in GF(256) at x = 1..4.
+	secret := []byte{0x42, 0x07}
+	shares := []Share{
+		{Index: 1, Value: []byte{0x41, 0x17}},
+		{Index: 2, Value: []byte{0x48, 0x27}},
+		{Index: 3, Value: []byte{0x4b, 0x37}},
+		{Index: 4, Value: []byte{0x66, 0x47}},
+	}
+	for _, subset := range subsets(len(shares), 3) {
+		picked := pick(shares, subset)
+		got, err := Combine(picked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Errorf("shares %v combine to %x, want %x", subset, got, secret)
+		}
+	}
+}
+
+func TestSplitCombineSubsets(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	for _, tc := range []struct{ n, k int }{{2, 2}, {3, 2}, {5, 3}, {6, 6}, {MAX_SHARES, 5}} {
+		shares, err := Split(secret, tc.n, tc.k)
+		if err != nil {
+			t.Fatalf("%d-of-%d: %v", tc.k, tc.n, err)
+		}
+		for _, subset := range subsets(tc.n, tc.k) {
+			got, err := Combine(pick(shares, subset))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, secret) {
+				t.Fatalf("%d-of-%d: shares %v combine to %x", tc.k, tc.n, subset, got)
+			}
+		}
+		// K-1 shares interpolate a different polynomial; with 32 random
+		// coefficients per share a match is practically impossible.
+		for _, subset := range subsets(tc.n, tc.k-1) {
+			got, err := Combine(pick(shares, subset))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Equal(got, secret) {
+				t.Fatalf("%d-of-%d: %d shares %v recovered the secret", tc.k, tc.n, tc.k-1, subset)
+			}
+		}
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	cases := []struct {
+		secret  []byte
+		n, k    int
+		message string
+	}{
+		{nil, 3, 2, "empty secret"},
+		{[]byte{1}, 0, 2, "no shares"},
+		{[]byte{1}, MAX_SHARES + 1, 2, "too many shares"},
+		{[]byte{1}, 3, 4, "threshold above shares"},
+		{[]byte{1}, 3, 1, "threshold of one"},
+		{[]byte{1}, 1, 1, "single share"},
+	}
+	for _, c := range cases {
+		if _, err := Split(c.secret, c.n, c.k); err == nil {
+			t.Errorf("%s: expected an error", c.message)
+		}
+	}
+}
+
+func TestCombineErrors(t *testing.T) {
+	if _, err := Combine(nil); err == nil {
+		t.Error("no shares: expected an error")
+	}
+	dup := []Share{{Index: 1, Value: []byte{1}}, {Index: 1, Value: []byte{2}}}
+	if _, err := Combine(dup); err == nil {
+		t.Error("duplicate index: expected an error")
+	}
+	zero := []Share{{Index: 0, Value: []byte{1}}, {Index: 1, Value: []byte{2}}}
+	if _, err := Combine(zero); err == nil {
+		t.Error("index 0: expected an error")
+	}
+	uneven := []Share{{Index: 1, Value: []byte{1}}, {Index: 2, Value: []byte{2, 3}}}
+	if _, err := Combine(uneven); err == nil {
+		t.Error("uneven lengths: expected an error")
+	}
+}
+
+// subsets returns every k-element subset of 0..n-1.
+func subsets(n, k int) [][]int {
+	var out [][]int
+	var walk func(start int, cur []int)
+	walk = func(start int, cur []int) {
+		if len(cur) == k {
+			out = append(out, append([]int(nil), cur...))
+			return
+		}
+		for i := start; i < n; i++ {
+			walk(i+1, append(cur, i))
+		}
+	}
+	walk(0, nil)
+	return out
+}
+
+func pick(shares []Share, idx []int) []Share {
+	out := make([]Share, len(idx))
+	for i, j := range idx {
+		out[i] = shares[j]
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package instancesmanager
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PeepoFrog/sekai_manager/src/cfg"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/supervisor"
+	tmrpc "github.com/PeepoFrog/sekai_manager/src/instances_manager/tm_rpc"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+const DefaultStatusTimeout = 5 * time.Second
+
+// InstanceStatus combines the process state of an instance with what its RPC reports.
+// RPC fields are only set when the node answered; Error holds the reason otherwise.
+type InstanceStatus struct {
+	Name        string           `json:"name"`
+	State       supervisor.State `json:"state"`
+	Pid         int              `json:"pid,omitempty"`
+	RPC         string           `json:"rpc"`
+	Height      int64            `json:"latest_block_height"`
+	BlockTime   time.Time        `json:"latest_block_time"`
+	CatchingUp  bool             `json:"catching_up"`
+	NodeID      string           `json:"node_id,omitempty"`
+	Peers       int              `json:"peers"`
+	VotingPower int64            `json:"voting_power"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// Status queries every registered instance concurrently.
+// Each RPC round trip is bounded by timeout; results keep the registry order.
+func (im *InstanceManager) Status(ctx context.Context, timeout time.Duration) []InstanceStatus {
+	im.mu.Lock()
+	instances := append([]types.InstanceConfig(nil), im.Instances...)
+	im.mu.Unlock()
+
+	sv := supervisor.New(im.Home)
+	client := &http.Client{Timeout: timeout}
+	out := make([]InstanceStatus, len(instances))
+
+	var wg sync.WaitGroup
+	for i, ic := range instances {
+		wg.Add(1)
+		go func(i int, ic types.InstanceConfig) {
+			defer wg.Done()
+			out[i] = instanceStatus(ctx, sv, client, ic, timeout)
+		}(i, ic)
+	}
+	wg.Wait()
+	return out
+}
+
+func instanceStatus(ctx context.Context, sv *supervisor.Supervisor, client *http.Client, ic types.InstanceConfig, timeout time.Duration) InstanceStatus {
+	st := InstanceStatus{Name: ic.Name}
+
+	state, pid, err := sv.State(ic.Name)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.State, st.Pid = state, pid
+
+	ab, err := cfg.BindingOf(ic)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.RPC = ab.RpcLaddr
+	if state != supervisor.StateRunning {
+		return st
+	}
+
+	rpc, err := tmrpc.NewClient(ab.RpcLaddr, client)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	status, err := rpc.Status(ctx)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.Height = status.SyncInfo.LatestBlockHeight
+	st.BlockTime = status.SyncInfo.LatestBlockTime
+	st.CatchingUp = status.SyncInfo.CatchingUp
+	st.NodeID = status.NodeInfo.ID
+	st.VotingPower = status.ValidatorInfo.VotingPower
+
+	netInfo, err := rpc.NetInfo(ctx)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.Peers = netInfo.NPeers
+	return st
+}
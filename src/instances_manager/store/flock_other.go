@@ -0,0 +1,25 @@
+//go:build !unix
+
+package store
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// lockFile falls back to an exclusively created lock file where flock is unavailable.
+func lockFile(path string) (func(), error) {
+	path += ".excl"
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
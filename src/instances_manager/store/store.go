@@ -0,0 +1,409 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/verify"
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	STORE_FOLDER_NAME string = "store"
+	ENTRY_META_FILE   string = "entry.toml"
+	PIN_FILE          string = "pinned"
+	// USED_FILE is touched whenever an entry is handed out; its mtime is LastUsed.
+	USED_FILE   string = "last_used"
+	BINARY_NAME string = "sekaid"
+
+	// PRUNE_GRACE keeps entries that were handed out recently, so an install that
+	// has not recorded its entry in the config yet does not lose its binary.
+	PRUNE_GRACE time.Duration = time.Hour
+
+	stagingFolder   = ".staging"
+	locksFolder     = ".locks"
+	downloadsFolder = ".downloads"
+)
+
+var ErrEntryNotFound = errors.New("store entry not found")
+
+// Store keeps sekaid binaries shared by all instances under <manager home>/store,
+// laid out as <version>/<os>-<arch>/<sha256 of the binary>/sekaid.
+type Store struct {
+	Root string
+}
+
+// Entry is one installed binary.
+type Entry struct {
+	ID          string    `toml:"id"`
+	Version     string    `toml:"version"`
+	OS          string    `toml:"os"`
+	Arch        string    `toml:"arch"`
+	Digest      string    `toml:"digest"`
+	AssetDigest string    `toml:"asset_digest,omitempty"`
+	Source      string    `toml:"source,omitempty"`
+	InstalledAt time.Time `toml:"installed_at"`
+
+	Dir      string    `toml:"-"`
+	Binary   string    `toml:"-"`
+	Pinned   bool      `toml:"-"`
+	LastUsed time.Time `toml:"-"`
+}
+
+// FetchFunc places the sekaid binary into stageDir and returns the digest of the
+// asset it came from.
+type FetchFunc func(stageDir string) (assetDigest string, err error)
+
+func New(managerHome string) *Store {
+	return &Store{Root: filepath.Join(managerHome, STORE_FOLDER_NAME)}
+}
+
+// EntryID builds the store id of a binary.
+func EntryID(version, goos, goarch, digest string) string {
+	return strings.Join([]string{version, goos + "-" + goarch, digest}, "/")
+}
+
+// Find returns the newest entry of version for the current platform that was
+// installed from source.
+func (s *Store) Find(version, source string) (*Entry, bool, error) {
+	dir := filepath.Join(s.Root, version, platform())
+	des, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var newest *Entry
+	for _, de := range des {
+		if !de.IsDir() {
+			continue
+		}
+		e, err := s.load(filepath.Join(dir, de.Name()))
+		if err != nil || e.Source != source {
+			continue
+		}
+		if newest == nil || e.InstalledAt.After(newest.InstalledAt) {
+			newest = e
+		}
+	}
+	return newest, newest != nil, nil
+}
+
+// Get loads an entry by id.
+func (s *Store) Get(id string) (*Entry, error) {
+	dir, err := s.entryDir(id)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.load(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrEntryNotFound, id)
+	}
+	return e, err
+}
+
+// Ensure returns the entry of version from source for the current platform,
+// installing it with fetch if missing. Concurrent calls for the same version, also
+// from other processes, are serialized by a file lock so the binary is fetched only
+// once; the same lock keeps Prune from removing the entry while it is handed out.
+func (s *Store) Ensure(version, source string, fetch FetchFunc) (*Entry, error) {
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	unlock, err := s.lock(version, platform())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	e, ok, err := s.Find(version, source)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return e, s.touch(e)
+	}
+	return s.install(version, source, fetch)
+}
+
+// Add always installs with fetch, for sources whose content may change under the
+// same version (local files, source checkouts). A binary identical to a stored one
+// resolves to the existing entry.
+func (s *Store) Add(version, source string, fetch FetchFunc) (*Entry, error) {
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	unlock, err := s.lock(version, platform())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.install(version, source, fetch)
+}
+
+// DownloadDir is where installers keep release assets while they download, so an
+// interrupted download resumes on the next install of the same version.
+func (s *Store) DownloadDir() string {
+	return filepath.Join(s.Root, downloadsFolder)
+}
+
+// lock serializes installs and prunes of one version and platform.
+func (s *Store) lock(version, platform string) (func(), error) {
+	if err := os.MkdirAll(filepath.Join(s.Root, locksFolder), 0o755); err != nil {
+		return nil, err
+	}
+	return lockFile(filepath.Join(s.Root, locksFolder, version+"-"+platform+".lock"))
+}
+
+// touch marks e as handed out now; the caller holds the version lock.
+func (s *Store) touch(e *Entry) error {
+	if err := os.WriteFile(filepath.Join(e.Dir, USED_FILE), nil, 0o644); err != nil {
+		return err
+	}
+	e.LastUsed = time.Now()
+	return nil
+}
+
+// install fetches into a staging dir and moves it to its content address; the caller
+// holds the version lock.
+func (s *Store) install(version, source string, fetch FetchFunc) (*Entry, error) {
+	if err := os.MkdirAll(filepath.Join(s.Root, stagingFolder), 0o755); err != nil {
+		return nil, err
+	}
+	stage, err := os.MkdirTemp(filepath.Join(s.Root, stagingFolder), version+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stage)
+
+	assetDigest, err := fetch(stage)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := verify.FileSHA256(filepath.Join(stage, BINARY_NAME))
+	if err != nil {
+		return nil, fmt.Errorf("fetched binary: %w", err)
+	}
+
+	e := &Entry{
+		ID:          EntryID(version, runtime.GOOS, runtime.GOARCH, digest),
+		Version:     version,
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Digest:      digest,
+		AssetDigest: assetDigest,
+		Source:      source,
+		InstalledAt: time.Now().UTC(),
+	}
+	b, err := toml.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(stage, ENTRY_META_FILE), b, 0o644); err != nil {
+		return nil, err
+	}
+
+	final := filepath.Join(s.Root, filepath.FromSlash(e.ID))
+	if err := os.MkdirAll(filepath.Dir(final), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(stage, final); err != nil {
+		if _, statErr := os.Stat(final); statErr != nil {
+			return nil, err
+		}
+		// identical content is already stored under the same digest
+	}
+	if err := os.Chmod(final, 0o755); err != nil {
+		return nil, err
+	}
+	e, err = s.load(final)
+	if err != nil {
+		return nil, err
+	}
+	return e, s.touch(e)
+}
+
+func checkVersion(version string) error {
+	if version == "" || strings.ContainsAny(version, `/\`) || version == "." || version == ".." {
+		return fmt.Errorf("invalid version %q", version)
+	}
+	return nil
+}
+
+// List returns all entries sorted by version, platform and install time.
+func (s *Store) List() ([]Entry, error) {
+	var out []Entry
+	metas, err := filepath.Glob(filepath.Join(s.Root, "*", "*", "*", ENTRY_META_FILE))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metas {
+		e, err := s.load(filepath.Dir(m))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Version != out[j].Version {
+			return out[i].Version < out[j].Version
+		}
+		if out[i].OS+out[i].Arch != out[j].OS+out[j].Arch {
+			return out[i].OS+out[i].Arch < out[j].OS+out[j].Arch
+		}
+		return out[i].InstalledAt.Before(out[j].InstalledAt)
+	})
+	return out, nil
+}
+
+// Pin protects the entries selected by idOrVersion from Prune; Unpin reverses it.
+func (s *Store) Pin(idOrVersion string) ([]Entry, error) {
+	return s.setPinned(idOrVersion, true)
+}
+
+func (s *Store) Unpin(idOrVersion string) ([]Entry, error) {
+	return s.setPinned(idOrVersion, false)
+}
+
+func (s *Store) setPinned(idOrVersion string, pinned bool) ([]Entry, error) {
+	entries, err := s.Select(idOrVersion)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		pin := filepath.Join(entries[i].Dir, PIN_FILE)
+		if pinned {
+			err = os.WriteFile(pin, nil, 0o644)
+		} else if err = os.Remove(pin); errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries[i].Pinned = pinned
+	}
+	return entries, nil
+}
+
+// Select returns the entry with the given id, or every entry of a version.
+func (s *Store) Select(idOrVersion string) ([]Entry, error) {
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for _, e := range all {
+		if e.ID == idOrVersion || e.Version == idOrVersion {
+			out = append(out, e)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEntryNotFound, idOrVersion)
+	}
+	return out, nil
+}
+
+// Prune deletes entries that are neither pinned, referenced nor handed out
+// within PRUNE_GRACE. referenced holds entry ids. Each entry is checked again and
+// removed under its version lock, so an install running alongside keeps its
+// binary. With dryRun nothing is deleted. Returns the (would-be) removed entries.
+func (s *Store) Prune(referenced map[string]bool, dryRun bool) ([]Entry, error) {
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var removed []Entry
+	for _, e := range all {
+		if referenced[e.ID] {
+			continue
+		}
+		ok, err := s.pruneEntry(e, dryRun)
+		if err != nil {
+			return removed, err
+		}
+		if ok {
+			removed = append(removed, e)
+		}
+	}
+	return removed, nil
+}
+
+func (s *Store) pruneEntry(e Entry, dryRun bool) (bool, error) {
+	unlock, err := s.lock(e.Version, e.OS+"-"+e.Arch)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	cur, err := s.load(e.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if cur.Pinned || time.Since(cur.LastUsed) < PRUNE_GRACE {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	if err := os.RemoveAll(cur.Dir); err != nil {
+		return false, err
+	}
+	removeEmptyParents(filepath.Dir(cur.Dir), s.Root)
+	return true, nil
+}
+
+func (s *Store) entryDir(id string) (string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed store id %q", id)
+	}
+	for _, p := range parts {
+		if p == "" || p == "." || p == ".." {
+			return "", fmt.Errorf("malformed store id %q", id)
+		}
+	}
+	return filepath.Join(s.Root, filepath.Join(parts...)), nil
+}
+
+func (s *Store) load(dir string) (*Entry, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ENTRY_META_FILE))
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := toml.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	e.Dir = dir
+	e.Binary = filepath.Join(dir, BINARY_NAME)
+	_, err = os.Stat(filepath.Join(dir, PIN_FILE))
+	e.Pinned = err == nil
+	e.LastUsed = e.InstalledAt
+	if st, err := os.Stat(filepath.Join(dir, USED_FILE)); err == nil && st.ModTime().After(e.LastUsed) {
+		e.LastUsed = st.ModTime()
+	}
+	return &e, nil
+}
+
+func removeEmptyParents(dir, root string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func platform() string {
+	return runtime.GOOS + "-" + runtime.GOARCH
+}
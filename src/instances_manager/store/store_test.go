@@ -0,0 +1,105 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fakeFetch(content string) FetchFunc {
+	return func(stageDir string) (string, error) {
+		return "asset", os.WriteFile(filepath.Join(stageDir, BINARY_NAME), []byte(content), 0o755)
+	}
+}
+
+func TestPruneKeepsRecentlyUsed(t *testing.T) {
+	s := New(t.TempDir())
+	e, err := s.Ensure("v0.4.1", "github:KiraCore/sekai", fakeFetch("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := s.Prune(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("pruned an entry handed out just now: %v", removed)
+	}
+
+	// age the entry past the grace period
+	old := time.Now().Add(-2 * PRUNE_GRACE)
+	if err := os.Chtimes(filepath.Join(e.Dir, USED_FILE), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := s.Prune(map[string]bool{e.ID: true}, false); err != nil || len(removed) != 0 {
+		t.Fatalf("pruned a referenced entry: %v, %v", removed, err)
+	}
+	// the entry was installed just now; backdate its metadata too
+	b, err := os.ReadFile(filepath.Join(e.Dir, ENTRY_META_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := []byte(replaceInstalledAt(string(b), old))
+	if err := os.WriteFile(filepath.Join(e.Dir, ENTRY_META_FILE), meta, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err = s.Prune(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].ID != e.ID {
+		t.Fatalf("removed %v, want %s", removed, e.ID)
+	}
+	if _, err := os.Stat(e.Dir); !os.IsNotExist(err) {
+		t.Fatalf("entry dir still exists: %v", err)
+	}
+}
+
+func TestPruneWaitsForInstall(t *testing.T) {
+	s := New(t.TempDir())
+	e, err := s.Ensure("v0.4.1", "github:KiraCore/sekai", fakeFetch("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * PRUNE_GRACE)
+	if err := os.Chtimes(filepath.Join(e.Dir, USED_FILE), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// an install of the same version holds the lock and hands the entry out again
+	unlock, err := s.lock(e.Version, platform())
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan []Entry)
+	go func() {
+		removed, _ := s.Prune(nil, false)
+		done <- removed
+	}()
+	select {
+	case removed := <-done:
+		t.Fatalf("prune did not wait for the lock: %v", removed)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := s.touch(e); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if removed := <-done; len(removed) != 0 {
+		t.Fatalf("pruned an entry handed out under the lock: %v", removed)
+	}
+}
+
+func replaceInstalledAt(meta string, at time.Time) string {
+	lines := strings.Split(meta, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "installed_at") {
+			lines[i] = "installed_at = " + at.UTC().Format(time.RFC3339)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
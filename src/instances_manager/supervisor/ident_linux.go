@@ -0,0 +1,34 @@
+//go:build linux
+
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// procIdentity tells a process apart from a later one with the same pid: the
+// boot id plus the start time in clock ticks since boot, from /proc.
+func procIdentity(pid int) (string, error) {
+	boot, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return "", err
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return "", err
+	}
+	// comm (field 2) may contain spaces and parentheses, fields are counted after its closing ')'
+	i := strings.LastIndexByte(string(stat), ')')
+	if i < 0 {
+		return "", fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	// starttime is field 22, the 20th after comm
+	if len(fields) < 20 {
+		return "", fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	return strings.TrimSpace(string(boot)) + "/" + fields[19], nil
+}
@@ -0,0 +1,24 @@
+//go:build unix && !linux
+
+package supervisor
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// procIdentity tells a process apart from a later one with the same pid by its
+// start time as reported by ps.
+func procIdentity(pid int) (string, error) {
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", fmt.Errorf("ps: %w", err)
+	}
+	ident := strings.Join(strings.Fields(string(out)), " ")
+	if ident == "" {
+		return "", fmt.Errorf("ps reported no start time for %d", pid)
+	}
+	return ident, nil
+}
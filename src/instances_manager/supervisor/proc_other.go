@@ -0,0 +1,34 @@
+//go:build !unix
+
+package supervisor
+
+import (
+	"os"
+	"syscall"
+)
+
+func detachedProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
+
+// signalProcess can only kill on platforms without POSIX signals.
+func signalProcess(pid int, _ syscall.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+// procIdentity is not available here; pid files only hold the pid.
+func procIdentity(pid int) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package supervisor
+
+import (
+	"os"
+	"syscall"
+)
+
+// detachedProcAttr puts the child into its own session so it outlives the CLI
+// and does not receive signals sent to the terminal's process group.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+func signalProcess(pid int, sig syscall.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Signal(sig)
+}
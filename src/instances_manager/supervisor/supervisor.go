@@ -0,0 +1,310 @@
+package supervisor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+const (
+	RUN_FOLDER_NAME  string = "run"
+	LOGS_FOLDER_NAME string = "logs"
+
+	DefaultStopTimeout = 30 * time.Second
+	DefaultStartGrace  = time.Second
+	DefaultBinaryName  = "sekaid"
+)
+
+// State is the run state of an instance as seen by the supervisor.
+type State string
+
+const (
+	StateRunning State = "running"
+	StateStopped State = "stopped"
+	// StateCrashed means a pid file is left behind but the process is gone or its
+	// pid now belongs to another process, i.e. the instance exited without being
+	// stopped through the supervisor.
+	StateCrashed State = "crashed"
+)
+
+var ErrNotRunning = errors.New("instance is not running")
+
+// ErrUnverifiedPid is returned instead of signalling a process the pid file can
+// not be matched with, e.g. a pid file written before identities were recorded.
+var ErrUnverifiedPid = errors.New("pid file does not identify the process")
+
+// Supervisor starts and stops sekaid processes and keeps their pid files and logs
+// under the manager home.
+type Supervisor struct {
+	RunDir string
+	LogDir string
+
+	// StartGrace is how long Start waits to catch a process that exits right away.
+	StartGrace time.Duration
+}
+
+// New returns a supervisor storing its state under managerHome.
+func New(managerHome string) *Supervisor {
+	return &Supervisor{
+		RunDir:     filepath.Join(managerHome, RUN_FOLDER_NAME),
+		LogDir:     filepath.Join(managerHome, LOGS_FOLDER_NAME),
+		StartGrace: DefaultStartGrace,
+	}
+}
+
+func (s *Supervisor) PidFile(name string) string {
+	return filepath.Join(s.RunDir, name+".pid")
+}
+
+func (s *Supervisor) StdoutLog(name string) string {
+	return filepath.Join(s.LogDir, name+".out.log")
+}
+
+func (s *Supervisor) StderrLog(name string) string {
+	return filepath.Join(s.LogDir, name+".err.log")
+}
+
+// BinaryPath returns the sekaid binary used by the instance.
+// Instances without an explicit binary use sekaid from PATH.
+func BinaryPath(ic types.InstanceConfig) (string, error) {
+	if ic.Binary != "" {
+		return ic.Binary, nil
+	}
+	return exec.LookPath(DefaultBinaryName)
+}
+
+// Start launches `sekaid start --home <ic.Home>` in the background.
+// stdout and stderr are appended to the instance log files.
+func (s *Supervisor) Start(ic types.InstanceConfig) (int, error) {
+	state, pid, err := s.State(ic.Name)
+	if err != nil {
+		return 0, err
+	}
+	if state == StateRunning {
+		return pid, fmt.Errorf("instance %q is already running (pid %d)", ic.Name, pid)
+	}
+
+	// sekaid would otherwise start over with a fresh home in place of the lost one
+	if st, err := os.Stat(ic.Home); err != nil {
+		return 0, fmt.Errorf("instance %q home: %w", ic.Name, err)
+	} else if !st.IsDir() {
+		return 0, fmt.Errorf("instance %q home %s is not a directory", ic.Name, ic.Home)
+	}
+
+	bin, err := BinaryPath(ic)
+	if err != nil {
+		return 0, fmt.Errorf("unable to find sekaid binary: %w", err)
+	}
+	if err := os.MkdirAll(s.RunDir, 0o755); err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(s.LogDir, 0o755); err != nil {
+		return 0, err
+	}
+
+	stdout, err := os.OpenFile(s.StdoutLog(ic.Name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer stdout.Close()
+	stderr, err := os.OpenFile(s.StderrLog(ic.Name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer stderr.Close()
+
+	cmd := exec.Command(bin, "start", "--home", ic.Home)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = detachedProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("unable to start %s: %w", bin, err)
+	}
+	pid = cmd.Process.Pid
+
+	exited := make(chan error, 1)
+	// Reap the child while this process lives so a stopped child never lingers as a zombie.
+	go func() { exited <- cmd.Wait() }()
+
+	ident, err := procIdentity(pid)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		if werr := <-exited; werr != nil {
+			if tail := s.tail(s.StderrLog(ic.Name)); tail != "" {
+				return 0, fmt.Errorf("instance %q exited right after start (%v): %s", ic.Name, werr, tail)
+			}
+		}
+		return 0, fmt.Errorf("unable to identify process %d: %w", pid, err)
+	}
+	if err := os.WriteFile(s.PidFile(ic.Name), []byte(strconv.Itoa(pid)+"\n"+ident+"\n"), 0o644); err != nil {
+		_ = cmd.Process.Kill()
+		return 0, fmt.Errorf("unable to write pid file: %w", err)
+	}
+
+	select {
+	case err := <-exited:
+		_ = os.Remove(s.PidFile(ic.Name))
+		return 0, fmt.Errorf("instance %q exited right after start (%v): %s", ic.Name, err, s.tail(s.StderrLog(ic.Name)))
+	case <-time.After(s.StartGrace):
+	}
+	return pid, nil
+}
+
+// Stop sends SIGTERM and waits up to timeout for the process to exit,
+// after which it is killed with SIGKILL.
+func (s *Supervisor) Stop(name string, timeout time.Duration) error {
+	state, pid, err := s.State(name)
+	if err != nil {
+		return err
+	}
+	switch state {
+	case StateStopped:
+		return fmt.Errorf("%w: %s", ErrNotRunning, name)
+	case StateCrashed:
+		return os.Remove(s.PidFile(name))
+	}
+
+	if err := s.verifyPid(name, pid); err != nil {
+		return err
+	}
+	if err := signalProcess(pid, syscall.SIGTERM); err != nil && processAlive(pid) {
+		return fmt.Errorf("unable to send SIGTERM to %d: %w", pid, err)
+	}
+	if waitExit(pid, timeout) {
+		return os.Remove(s.PidFile(name))
+	}
+	return s.Kill(name)
+}
+
+// Kill sends SIGKILL right away.
+func (s *Supervisor) Kill(name string) error {
+	state, pid, err := s.State(name)
+	if err != nil {
+		return err
+	}
+	switch state {
+	case StateStopped:
+		return fmt.Errorf("%w: %s", ErrNotRunning, name)
+	case StateCrashed:
+		return os.Remove(s.PidFile(name))
+	}
+
+	if err := s.verifyPid(name, pid); err != nil {
+		return err
+	}
+	if err := signalProcess(pid, syscall.SIGKILL); err != nil && processAlive(pid) {
+		return fmt.Errorf("unable to send SIGKILL to %d: %w", pid, err)
+	}
+	if !waitExit(pid, 5*time.Second) {
+		return fmt.Errorf("process %d did not exit after SIGKILL", pid)
+	}
+	return os.Remove(s.PidFile(name))
+}
+
+// Restart stops the instance if it is running and starts it again.
+func (s *Supervisor) Restart(ic types.InstanceConfig, timeout time.Duration) (int, error) {
+	if err := s.Stop(ic.Name, timeout); err != nil && !errors.Is(err, ErrNotRunning) {
+		return 0, err
+	}
+	return s.Start(ic)
+}
+
+// State reports whether the instance is running. pid is 0 when no pid file exists.
+// A live process whose identity differs from the one recorded at start is a
+// reused pid, so the instance counts as crashed.
+func (s *Supervisor) State(name string) (State, int, error) {
+	pid, ident, err := s.readPidFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return StateStopped, 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if !processAlive(pid) {
+		return StateCrashed, pid, nil
+	}
+	if ident != "" {
+		if cur, err := procIdentity(pid); err == nil && cur != "" && cur != ident {
+			return StateCrashed, pid, nil
+		}
+	}
+	return StateRunning, pid, nil
+}
+
+// verifyPid makes sure pid is still the process Start launched before it is signalled.
+func (s *Supervisor) verifyPid(name string, pid int) error {
+	_, ident, err := s.readPidFile(name)
+	if err != nil {
+		return err
+	}
+	cur, err := procIdentity(pid)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrUnverifiedPid, s.PidFile(name), err)
+	}
+	if cur == "" {
+		// the platform can not tell processes apart beyond their pid
+		return nil
+	}
+	if ident == "" {
+		return fmt.Errorf("%w: %s has no process identity, check pid %d and remove the file by hand", ErrUnverifiedPid, s.PidFile(name), pid)
+	}
+	if cur != ident {
+		return fmt.Errorf("%w: pid %d now belongs to another process", ErrUnverifiedPid, pid)
+	}
+	return nil
+}
+
+// readPidFile returns the pid and the process identity recorded by Start; the
+// identity is empty for pid files of older versions.
+func (s *Supervisor) readPidFile(name string) (int, string, error) {
+	b, err := os.ReadFile(s.PidFile(name))
+	if err != nil {
+		return 0, "", err
+	}
+	lines := strings.SplitN(strings.TrimSpace(string(b)), "\n", 2)
+	pid, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil || pid <= 0 {
+		return 0, "", fmt.Errorf("malformed pid file %s", s.PidFile(name))
+	}
+	var ident string
+	if len(lines) == 2 {
+		ident = strings.TrimSpace(lines[1])
+	}
+	return pid, ident, nil
+}
+
+func waitExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if !processAlive(pid) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// tail returns the last few lines of a log file for error messages.
+func (s *Supervisor) tail(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	if len(lines) > 10 {
+		lines = lines[len(lines)-10:]
+	}
+	return string(bytes.Join(lines, []byte("\n")))
+}
@@ -0,0 +1,227 @@
+//go:build unix
+
+package supervisor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+const (
+	// fakeSekaid records its arguments and runs until SIGTERM.
+	fakeSekaid = `#!/bin/sh
+echo "$@" > "$(dirname "$0")/args"
+trap 'exit 0' TERM
+while :; do sleep 0.1; done
+`
+	// stubbornSekaid ignores SIGTERM and only goes away with SIGKILL.
+	stubbornSekaid = `#!/bin/sh
+trap '' TERM
+while :; do sleep 0.1; done
+`
+	// crashingSekaid fails right after start.
+	crashingSekaid = `#!/bin/sh
+echo "panic: genesis file not found" >&2
+exit 3
+`
+)
+
+func setup(t *testing.T, script string) (*Supervisor, types.InstanceConfig) {
+	t.Helper()
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "bin", "sekaid")
+	if err := os.MkdirAll(filepath.Dir(bin), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := New(filepath.Join(dir, "manager"))
+	s.StartGrace = 300 * time.Millisecond
+	ic := types.InstanceConfig{Name: "node", Home: filepath.Join(dir, "home"), Binary: bin}
+	if err := os.MkdirAll(ic.Home, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Kill(ic.Name) })
+	return s, ic
+}
+
+// unrelated starts a process the supervisor did not launch.
+func unrelated(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	return cmd.Process.Pid
+}
+
+func assertState(t *testing.T, s *Supervisor, name string, want State) int {
+	t.Helper()
+	state, pid, err := s.State(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != want {
+		t.Fatalf("state %q, want %q", state, want)
+	}
+	return pid
+}
+
+func TestStartStop(t *testing.T) {
+	s, ic := setup(t, fakeSekaid)
+	assertState(t, s, ic.Name, StateStopped)
+
+	pid, err := s.Start(ic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := assertState(t, s, ic.Name, StateRunning); got != pid {
+		t.Fatalf("state pid %d, started %d", got, pid)
+	}
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(ic.Binary), "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(args)), "start --home "+ic.Home; got != want {
+		t.Fatalf("args %q, want %q", got, want)
+	}
+	if _, err := s.Start(ic); err == nil {
+		t.Fatal("second start succeeded")
+	}
+
+	if err := s.Stop(ic.Name, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if processAlive(pid) {
+		t.Fatalf("process %d still alive", pid)
+	}
+	assertState(t, s, ic.Name, StateStopped)
+	if err := s.Stop(ic.Name, time.Second); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("stop of a stopped instance: %v", err)
+	}
+}
+
+func TestStopEscalatesToKill(t *testing.T) {
+	s, ic := setup(t, stubbornSekaid)
+	pid, err := s.Start(ic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Stop(ic.Name, 300*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if processAlive(pid) {
+		t.Fatalf("process %d still alive", pid)
+	}
+	assertState(t, s, ic.Name, StateStopped)
+}
+
+func TestStartCrash(t *testing.T) {
+	s, ic := setup(t, crashingSekaid)
+	_, err := s.Start(ic)
+	if err == nil || !strings.Contains(err.Error(), "genesis file not found") {
+		t.Fatalf("expected the stderr tail in the error, got %v", err)
+	}
+	assertState(t, s, ic.Name, StateStopped)
+}
+
+func TestStartMissingHome(t *testing.T) {
+	s, ic := setup(t, fakeSekaid)
+	if err := os.Remove(ic.Home); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Start(ic); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("start without home: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(ic.Binary), "args")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("sekaid was launched without its home")
+	}
+	assertState(t, s, ic.Name, StateStopped)
+}
+
+func TestCrashedAfterExit(t *testing.T) {
+	s, ic := setup(t, fakeSekaid)
+	pid, err := s.Start(ic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the process dies behind the supervisor's back
+	if err := signalProcess(pid, syscall.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	if !waitExit(pid, 5*time.Second) {
+		t.Fatalf("process %d did not exit", pid)
+	}
+	assertState(t, s, ic.Name, StateCrashed)
+	if err := s.Stop(ic.Name, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	assertState(t, s, ic.Name, StateStopped)
+}
+
+func TestReusedPidIsNotSignalled(t *testing.T) {
+	s, ic := setup(t, fakeSekaid)
+	pid := unrelated(t)
+	if err := os.MkdirAll(s.RunDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// a pid file left from before a reboot now names an unrelated process
+	pidfile := strconv.Itoa(pid) + "\nstale-identity\n"
+	for _, stop := range []func() error{
+		func() error { return s.Stop(ic.Name, time.Second) },
+		func() error { return s.Kill(ic.Name) },
+	} {
+		if err := os.WriteFile(s.PidFile(ic.Name), []byte(pidfile), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		assertState(t, s, ic.Name, StateCrashed)
+		if err := stop(); err != nil {
+			t.Fatal(err)
+		}
+		assertState(t, s, ic.Name, StateStopped)
+		if !processAlive(pid) {
+			t.Fatalf("unrelated process %d was signalled", pid)
+		}
+	}
+}
+
+func TestLegacyPidFileIsNotSignalled(t *testing.T) {
+	s, ic := setup(t, fakeSekaid)
+	pid := unrelated(t)
+	if err := os.MkdirAll(s.RunDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.PidFile(ic.Name), []byte(strconv.Itoa(pid)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	assertState(t, s, ic.Name, StateRunning)
+	if err := s.Stop(ic.Name, time.Second); !errors.Is(err, ErrUnverifiedPid) {
+		t.Fatalf("stop: %v", err)
+	}
+	if err := s.Kill(ic.Name); !errors.Is(err, ErrUnverifiedPid) {
+		t.Fatalf("kill: %v", err)
+	}
+	if !processAlive(pid) {
+		t.Fatalf("unrelated process %d was signalled", pid)
+	}
+	if _, err := os.Stat(s.PidFile(ic.Name)); err != nil {
+		t.Fatalf("pid file removed: %v", err)
+	}
+	_ = os.Remove(s.PidFile(ic.Name))
+}
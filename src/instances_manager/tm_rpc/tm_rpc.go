@@ -0,0 +1,199 @@
+package tmrpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client talks to the Tendermint RPC of a sekaid node (the [rpc] laddr in config.toml).
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewClient accepts http(s) URLs as well as Tendermint listen addresses like tcp://0.0.0.0:26657.
+func NewClient(endpoint string, client *http.Client) (*Client, error) {
+	base, err := EndpointFromLaddr(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{BaseURL: base, HTTP: client}, nil
+}
+
+// EndpointFromLaddr turns a listen address into a URL usable by an HTTP client.
+// tcp:// becomes http:// and wildcard hosts are replaced with loopback.
+func EndpointFromLaddr(laddr string) (string, error) {
+	laddr = strings.TrimSpace(laddr)
+	if laddr == "" {
+		return "", errors.New("empty rpc address")
+	}
+	if !strings.Contains(laddr, "://") {
+		laddr = "http://" + laddr
+	}
+	u, err := url.Parse(laddr)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "tcp" {
+		u.Scheme = "http"
+	}
+	switch u.Hostname() {
+	case "", "0.0.0.0", "::":
+		u.Host = "127.0.0.1:" + u.Port()
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+// RPCError is an error returned by the node inside a JSON-RPC response.
+type RPCError struct {
+	Code    int
+	Message string
+	Data    string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s %s", e.Code, e.Message, e.Data)
+}
+
+// call does GET <base>/<method>?<query> and decodes the result into out.
+func (c *Client) call(ctx context.Context, method string, query url.Values, out any) error {
+	u := c.BaseURL + "/" + method
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var r rpcResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return fmt.Errorf("%s: HTTP %d: unable to decode response: %w", method, resp.StatusCode, err)
+	}
+	if r.Error != nil {
+		return &RPCError{Code: r.Error.Code, Message: r.Error.Message, Data: r.Error.Data}
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s: HTTP %d", method, resp.StatusCode)
+	}
+	return json.Unmarshal(r.Result, out)
+}
+
+type NodeInfo struct {
+	ID         string `json:"id"`
+	ListenAddr string `json:"listen_addr"`
+	Network    string `json:"network"`
+	Version    string `json:"version"`
+	Moniker    string `json:"moniker"`
+}
+
+type SyncInfo struct {
+	LatestBlockHeight int64     `json:"latest_block_height,string"`
+	LatestBlockTime   time.Time `json:"latest_block_time"`
+	CatchingUp        bool      `json:"catching_up"`
+}
+
+type ValidatorInfo struct {
+	Address     string `json:"address"`
+	VotingPower int64  `json:"voting_power,string"`
+}
+
+type Status struct {
+	NodeInfo      NodeInfo      `json:"node_info"`
+	SyncInfo      SyncInfo      `json:"sync_info"`
+	ValidatorInfo ValidatorInfo `json:"validator_info"`
+}
+
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var s Status
+	if err := c.call(ctx, "status", nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+type NetInfo struct {
+	Listening bool `json:"listening"`
+	NPeers    int  `json:"n_peers,string"`
+}
+
+func (c *Client) NetInfo(ctx context.Context) (*NetInfo, error) {
+	var n NetInfo
+	if err := c.call(ctx, "net_info", nil, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+type genesisChunk struct {
+	Chunk int    `json:"chunk,string"`
+	Total int    `json:"total,string"`
+	Data  string `json:"data"`
+}
+
+// Genesis returns genesis.json exactly as the node stores it, so its checksum can be compared.
+// It uses /genesis_chunked and falls back to /genesis for nodes without chunking support,
+// in which case the document is the node's re-encoding of the genesis.
+func (c *Client) Genesis(ctx context.Context) ([]byte, error) {
+	var out []byte
+	for n, total := 0, 1; n < total; n++ {
+		var chunk genesisChunk
+		err := c.call(ctx, "genesis_chunked", url.Values{"chunk": {strconv.Itoa(n)}}, &chunk)
+		if err != nil {
+			if n == 0 {
+				return c.genesisWhole(ctx, err)
+			}
+			return nil, fmt.Errorf("genesis chunk %d/%d: %w", n, total, err)
+		}
+		if chunk.Total <= 0 || chunk.Chunk != n {
+			return nil, fmt.Errorf("genesis chunk %d: unexpected chunk %d of %d", n, chunk.Chunk, chunk.Total)
+		}
+		total = chunk.Total
+		data, err := base64.StdEncoding.DecodeString(chunk.Data)
+		if err != nil {
+			return nil, fmt.Errorf("genesis chunk %d: %w", n, err)
+		}
+		out = append(out, data...)
+	}
+	return out, nil
+}
+
+func (c *Client) genesisWhole(ctx context.Context, chunkedErr error) ([]byte, error) {
+	var r struct {
+		Genesis json.RawMessage `json:"genesis"`
+	}
+	if err := c.call(ctx, "genesis", nil, &r); err != nil {
+		return nil, fmt.Errorf("genesis_chunked: %v; genesis: %w", chunkedErr, err)
+	}
+	return r.Genesis, nil
+}
@@ -0,0 +1,33 @@
+package instancesmanager
+
+import (
+	"github.com/PeepoFrog/sekai_manager/src/cfg"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/gitres"
+	"github.com/PeepoFrog/sekai_manager/src/instances_manager/installer/github_installer/verify"
+	mnemonicderiver "github.com/PeepoFrog/sekai_manager/src/instances_manager/mnemonic_deriver"
+	"github.com/PeepoFrog/sekai_manager/src/types"
+)
+
+// ConfigValidators are the field checks cfg validates the manager config with.
+var ConfigValidators = cfg.Validators{
+	TrustedKeys: func(keys []string) error {
+		_, err := verify.ParsePublicKeys(keys)
+		return err
+	},
+	AssetRules: func(rules types.AssetRules) error {
+		return gitres.AssetRules(rules).Validate()
+	},
+	VersionSpec: gitres.ValidateVersionSpec,
+	Version: func(version string) error {
+		_, err := gitres.ParseVersion(version)
+		return err
+	},
+	Source: func(source string) error {
+		_, _, err := installer.ParseSource(source)
+		return err
+	},
+	Derivation: func(d types.KeyDerivation) error {
+		return mnemonicderiver.Derivation(d).Validate()
+	},
+}
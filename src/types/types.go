@@ -3,15 +3,104 @@ package types
 // InstanceConfig describes one managed instance.
 // TOML will render this as an array of tables: [[instances]]
 type InstanceConfig struct {
-	Name          string `toml:"name"`
-	Home          string `toml:"home"`
-	PortRange     int    `toml:"port_range"`
-	SekaidVersion string `toml:"sekaid_version"`
+	Name      string `toml:"name"`
+	Home      string `toml:"home"`
+	PortRange int    `toml:"port_range"`
+	ChainID   string `toml:"chain_id,omitempty"`
+	Moniker   string `toml:"moniker,omitempty"`
+
+	// SekaidVersion is an exact tag, "latest", "latest-rc" or a constraint like "~0.4.0".
+	// ResolvedVersion is the tag it resolved to at install time and SekaidDigest the
+	// verified digest of the release asset the binary came from, empty if the asset
+	// was installed with verification skipped or came from a local or build source.
+	// SekaidSource is where the binary came from, e.g. "github:KiraCore/sekai" or
+	// "build:/src/sekai". StoreEntry is the id of the shared binary store entry that
+	// Binary points into.
+	SekaidVersion   string `toml:"sekaid_version"`
+	SekaidSource    string `toml:"sekaid_source,omitempty"`
+	ResolvedVersion string `toml:"resolved_version,omitempty"`
+	SekaidDigest    string `toml:"sekaid_digest,omitempty"`
+	StoreEntry      string `toml:"store_entry,omitempty"`
+	Binary          string `toml:"binary,omitempty"`
+	// Build is what the binary itself reported after install.
+	Build BuildInfo `toml:"build,omitempty"`
+	// Derivation records how the instance keys were derived from the master
+	// mnemonic; it is empty when the keys were not derived.
+	Derivation KeyDerivation `toml:"derivation,omitempty"`
+
+	Addresses AddressBinding `toml:"addresses"`
+}
+
+// BuildInfo is the persisted form of installer.BuildInfo, as reported by
+// `sekaid version --long`. Field set must stay identical so the two convert directly.
+type BuildInfo struct {
+	Version   string `toml:"version,omitempty"`
+	Commit    string `toml:"commit,omitempty"`
+	GoVersion string `toml:"go_version,omitempty"`
+}
+
+// KeyDerivation is the persisted form of mnemonicderiver.Derivation.
+// Field set must stay identical so the two convert directly.
+type KeyDerivation struct {
+	Scheme string `toml:"scheme,omitempty"`
+	Index  uint32 `toml:"index,omitempty"`
+	Name   string `toml:"name,omitempty"`
+	Prefix string `toml:"prefix,omitempty"`
+	Path   string `toml:"path,omitempty"`
+}
+
+// AddressBinding is the persisted form of cfg.AddressBinding.
+// Field set must stay identical to cfg.AddressBinding so the two convert directly.
+type AddressBinding struct {
+	ApiAddress      string `toml:"api_address"`
+	RossettaAddress string `toml:"rossetta_address"`
+	GrpcAddress     string `toml:"grpc_address"`
+	GrpcWebAddress  string `toml:"grpc_web_address"`
+
+	ProxyApp                            string `toml:"proxy_app"`
+	RpcLaddr                            string `toml:"rpc_laddr"`
+	RpcPprofLaddr                       string `toml:"rpc_pprof_laddr"`
+	P2PLaddr                            string `toml:"p2p_laddr"`
+	InstrumentationPrometheusListenAddr string `toml:"prometheus_listen_addr"`
+
+	Node string `toml:"node"`
 }
 
 // ManagerConfig is the root of the config file.
 type ManagerConfig struct {
-	Home       string           `toml:"home"`
-	ConfigPath string           `toml:"config_path"`
-	Instances  []InstanceConfig `toml:"instances,omitempty"`
+	SchemaVersion int    `toml:"schema_version"`
+	Home          string `toml:"home"`
+	ConfigPath    string `toml:"config_path"`
+
+	// TrustedKeys are pinned ed25519 public keys (base64 or hex) that must sign release checksums.
+	TrustedKeys []string `toml:"trusted_keys,omitempty"`
+	// AssetRules override how release assets are picked for this machine.
+	AssetRules AssetRules `toml:"asset_rules,omitempty"`
+	// GitHub configures access to the GitHub (Enterprise) API.
+	GitHub GitHubConfig `toml:"github,omitempty"`
+
+	Instances []InstanceConfig `toml:"instances,omitempty"`
+}
+
+// AssetRules mirrors gitres.AssetRules for cfg.toml. Empty fields keep the defaults:
+// the running OS/arch and the built-in format preference (deb, tar.gz, tar.zst,
+// tar.xz, zip, binary).
+type AssetRules struct {
+	OS      string              `toml:"os,omitempty"`
+	Arch    string              `toml:"arch,omitempty"`
+	Aliases map[string][]string `toml:"aliases,omitempty"`
+	Formats []string            `toml:"formats,omitempty"`
+	Exclude []string            `toml:"exclude,omitempty"`
+}
+
+// GitHubConfig holds GitHub API settings. A token in the environment
+// (SEKAI_MANAGER_GITHUB_TOKEN, GITHUB_TOKEN, GH_TOKEN) wins over Token.
+type GitHubConfig struct {
+	// APIBaseURL points at GitHub Enterprise (https://host/api/v3) or a mirror.
+	APIBaseURL string `toml:"api_base_url,omitempty"`
+	Token      string `toml:"token,omitempty"`
+	// RateLimitWait is how long to wait for a rate limit reset before failing, e.g. "10m".
+	RateLimitWait string `toml:"rate_limit_wait,omitempty"`
+	// NoCache disables the ETag cache of release metadata.
+	NoCache bool `toml:"no_cache,omitempty"`
 }